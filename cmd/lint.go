@@ -0,0 +1,1128 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/linter"
+	"github.com/open-policy-agent/opa/runtime"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/util"
+	"github.com/spf13/cobra"
+)
+
+// Color modes accepted by --color.
+const (
+	lintColorAuto   = "auto"
+	lintColorAlways = "always"
+	lintColorNever  = "never"
+)
+
+// Exit codes returned by the lint command.
+const (
+	lintExitOK         = 0 // no violations were found
+	lintExitViolations = 1 // one or more violations were found
+	lintExitError      = 2 // policies could not be loaded, compiled, or linted
+)
+
+type lintCommandParams struct {
+	format       string
+	output       string
+	color        string
+	noColor      bool
+	quiet        bool
+	failOn       string
+	maxIssues    int
+	discover     bool
+	suiteName    string
+	mdDetails    int
+	mdMaxSize    int
+	template     string
+	tmplFile     string
+	queries      []string
+	jsonc        bool
+	sample       string
+	sampleCount  int
+	sampleSeed   string
+	ignore       []string
+	filter       string
+	showLine     bool
+	configs      bool
+	configFiles  []string
+	rules        []string
+	noDefaults   bool
+	printRules   bool
+	printParsed  bool
+	timeout      time.Duration
+	disabled     []string
+	enable       []string
+	disable      []string
+	severity     []string
+	lintConfig   string
+	verbose      bool
+	printConfigs bool
+}
+
+func init() {
+
+	params := lintCommandParams{}
+
+	outputFormat := util.NewEnumFlag("", linter.Formats)
+	failOn := util.NewEnumFlag(linter.SeverityError, linter.Severities)
+	colorMode := util.NewEnumFlag(lintColorAuto, []string{lintColorAuto, lintColorAlways, lintColorNever})
+
+	lintCommand := &cobra.Command{
+		Use:   "lint",
+		Short: "Check policies against lint rules",
+		Long: `Check policies against lint rules written in Rego.
+
+The 'lint' command loads the policies and data contained in the given files and
+directories, compiles them, and evaluates the lint query. The lint rules are
+either included in the loaded files or loaded from separate files and
+directories with --rules. Lint rules loaded with --rules are compiled with the
+policies but they are not linted themselves. Each --rules path must contain at
+least one package under the namespace of the lint query (e.g., data.system.lint
+for the default query). If no lint rules are found, the default lint rules are
+used unless --no-default-rules is given. The default lint rules can be printed
+with --print-default-rules to use as a starting point. The policies being
+linted are provided to the lint rules as the input document:
+
+	input.modules[<file>]    # JSON representation of the module's AST
+
+Data files are loaded the same way as with 'opa run' except that YAML files
+containing multiple documents (separated by "---") are loaded as an array of
+the documents.
+
+Each string or object produced by the lint query is reported as an issue.
+Strings are reported as messages without a location and identified by the
+query (e.g., "system.lint.deny"). Objects must contain a "message" and may
+contain a "location" ({"file", "row", "col"} and optionally "end_row" and
+"end_col"), an "id", and a "severity". Locations are optional but strongly
+encouraged since they attribute the issue to a file. Values that do not have
+this shape are reported as a linter configuration error that names
+the lint rules that may have produced them. For example:
+
+	package system.lint
+
+	deny[{"message": msg, "location": rule.location}] {
+		rule = input.modules[_].rules[_]
+		rule.head.name = "foo"
+		msg = "rules must not be named foo"
+	}
+
+If the lint query does not refer to any rules but the lint rules loaded with
+--rules define "deny", "warn", or "info" rules in another package, that package
+is used instead (e.g., data.lint.style.deny for "package lint.style"). If more
+than one package defines such rules, or if --auto-discover=false is given, the
+command fails and lists the packages. The "deny" rules of the files being
+linted are never used as lint rules.
+
+Several lint queries can be given by repeating --query, e.g., to evaluate
+organization-wide and team-specific rules in one run. Each issue is then
+tagged with the "query" that produced it and issues produced by more than one
+query are reported once. If some of the queries fail, the issues of the other
+queries are still reported, the failures are listed in the report's
+"query_errors", and the command exits with status 2. Lint rules are not
+auto-discovered if several queries are given.
+
+If the lint query refers to a "deny" rule (as the default query does), the
+"warn" and "info" rules in the same package are evaluated as well. Issues
+produced by these rules have the "warn" and "info" severity unless they
+contain a "severity". Issues produced by "deny" are errors. The "warn" and
+"info" rules are optional.
+
+Each issue is identified by its "id". Issues without an "id" are identified by
+the rule that produced them, e.g., "system.lint.deny". Issues can be dropped
+by identifier with --disable-rule, which accepts glob patterns such as
+'style/*' and can be repeated. If --enable-rule is given, only the issues of
+matching rules are reported. Dropped issues do not affect the exit status and
+are counted separately in the summary.
+
+The severity of the issues of a rule can be overridden with --severity, e.g.,
+--severity no-todo-comments=warn, or in a lint settings file:
+
+	severity:
+	  no-todo-comments: warn
+	  unsafe-builtin: error
+
+The settings are read from the file given with --lint-config or from
+` + linter.LintConfigFile + ` in the working directory. Overrides given with
+--severity take precedence over the settings file. Overrides are applied
+before --fail-on is evaluated. Overrides for rules that do not produce any
+issues are allowed; they are listed with --verbose so that typos can be
+caught.
+
+Lint settings files can also disable rules and ignore files:
+
+	disable:
+	  - style/*
+	ignore:
+	  - gen/*
+
+An ` + linter.LintConfigFile + ` file in a linted directory applies to the files
+under that directory, in addition to the settings of its parent directories.
+Severity overrides of deeper directories take precedence; disabled rules and
+ignore patterns are combined. Ignore patterns are relative to the directory of
+the settings file. The settings that apply to each file can be printed with
+--print-lint-config; --verbose lists the settings files that are used.
+
+Issues on a single line can be suppressed with a comment on the line or on
+the line above:
+
+	# opa-lint:ignore no-foo,no-bar legacy rule kept for compatibility
+	foo = true
+
+The comment names the rules to suppress (glob patterns are allowed) followed
+by an optional justification. A comment without rule ids suppresses all rules
+on the line; this is reported as a warning. Suppressed issues do not affect
+the exit status and are counted separately in the summary. Comments that did
+not suppress any issues are listed with --verbose.
+
+Rules can also be suppressed with the "custom.lint.ignore" annotation of a
+METADATA block:
+
+	# METADATA
+	# scope: package
+	# custom:
+	#   lint:
+	#     ignore: ["no-foo", "style/*"]
+	package authz
+
+An annotation on a package applies to the file that declares the package
+("package" scope, the default) or to all files of the package and its
+subpackages ("subpackages" scope). An annotation on a rule applies to the rule
+("rule" scope, the default) or to all rules of the package with the same name
+("document" scope). The most specific scope that declares lint ignores
+decides, e.g., "ignore: []" on a rule reports all of its issues even if its
+package ignores some rules.
+
+With --show-line, each issue also includes the text of the source line that its
+location refers to.
+
+In addition to the lint rules, the following built-in checks are run. Built-in
+checks can be disabled with --disable-builtin.
+
+	builtin/conflicting-default    # default rule shadows an import
+
+Files can be excluded from linting with --ignore. Patterns without a path
+separator match any element of the file path (e.g., "*_test.rego" or
+"vendor"); patterns with a separator match the path or one of its parent
+directories. Ignored files are still compiled.
+
+OPA configuration files can be linted along with the policies by passing
+--include-config. Files named config.yaml or config.yml (and files given with
+--config-file) are then provided to the lint rules as input.opa_configs
+instead of being loaded as data. Each configuration contains the parsed
+"config" and the "keys" it defines, with the path, value, and location of
+every key. For example:
+
+	deny[{"message": msg, "location": k.location}] {
+		k = input.opa_configs[_].keys[_]
+		k.path[_] = "credentials"
+		k.key = "token"
+		not startswith(k.value, "${")
+		msg = "credentials must not be stored in plaintext"
+	}
+
+Linting can also be restricted to files whose paths match a regular expression
+with --filter (e.g., --filter 'authz/.*'). The expression is not anchored.
+Files that do not match are still compiled.
+
+Large sets of policies can be linted incrementally with --sample or
+--sample-count. The sampled modules are selected deterministically from the
+--sample-seed so runs with the same seed (by default, the same day) lint the
+same modules. All modules are still compiled, and the report records the
+sample so that the results can be identified as partial.
+
+The report is printed in the --format given. The pretty format prints the
+issues grouped by file, each followed by the offending source line with a caret
+under the issue's column. The json format prints a report object:
+
+	{
+	  "issues": [{"file", "row", "col", "end_row", "end_col", "message",
+	              "rule_id", "severity", "extra", "line"}, ...],
+	  "sample": {...},          # only set with --sample or --sample-count
+	  "termination": {"reason", "limit", "unevaluated"}
+	}
+
+The junit format reports each linted file as a test suite containing a failed
+test case for each issue. Files without issues contain a passing test case.
+
+The checkstyle format prints a file element for each file with issues. The
+rule id is reported as the source of each error and the severity defaults to
+error unless the issue's severity is "warning" or "info".
+
+The github format prints GitHub Actions workflow commands (e.g.,
+"::error file=a.rego,line=3,col=1::[rule-id] message") so that issues are shown
+as annotations.
+
+The gitlab format prints a GitLab Code Quality report. Severities are mapped
+to Code Quality severities as follows: "info" to info, "warning" to minor, and
+all other severities to major. Fingerprints are computed from the rule id, the
+file, and the content of the issue's source line, so they stay the same when
+unrelated lines move. Issues without a file cannot be shown by GitLab and are
+omitted with a warning. Issues without a row are reported at line 1.
+
+The tap format prints a TAP test point for each linted file. Files with issues
+fail and list their issues in a YAML diagnostic block.
+
+The rdjson format prints reviewdog diagnostics that can be piped into
+"reviewdog -f=rdjson".
+
+The teamcity format prints TeamCity service messages: an inspectionType for
+each lint rule and built-in check followed by an inspection for each issue.
+Lint rules whose "id" is computed during evaluation are only listed if they
+report issues.
+
+The azure format prints Azure Pipelines logging commands (##vso[task.logissue])
+for each issue. The task is marked as failed if any issues have the --fail-on
+severity or higher and as succeeded with issues otherwise.
+
+The html format prints a self-contained HTML report that summarizes the issues
+by rule and by file and lists the issues of each file with their source lines.
+
+The markdown format prints a summary line and a table of the issues for use in
+pull request comments. If there are more than --markdown-details issues, the
+issues of each file are shown in a collapsible section. The output is
+truncated to --markdown-max-size bytes.
+
+The go-template format executes the text/template given with --template or
+--template-file against the report. The following fields are available:
+
+	.Issues                   # issues sorted by file and position
+	.Files                    # [{.Name, .Issues}] for each file with issues
+	.Summary.Issues           # number of issues
+	.Summary.Files            # number of files with issues
+	.Summary.Linted           # number of linted files
+	.Summary.BySeverity       # number of issues by severity
+	.Sample                   # see the json format
+	.Termination              # {.Reason, .Limit, .Unevaluated}
+
+Each issue has the fields .File, .Row, .Col, .EndRow, .EndCol, .Message,
+.RuleID, .Severity, .Extra, .Line, and .Query. In addition to the text/template
+builtins, templates can call "json", "upper", and "relpath" (which returns a
+path relative to the working directory). For example:
+
+	--template '{{range .Issues}}{{relpath .File}}:{{.Row}}: {{.Message}}{{"\n"}}{{end}}'
+
+The compact format prints each issue on a single line for use with editors
+(e.g., Vim's quickfix list or Emacs' compilation-mode):
+
+	policies/a.rego:3:1: rule names should be lowercase [default/rule-name-case]
+	policies/b.rego:7: warning: rule is unused [unused-rule]
+
+After the issues, a summary of the number of scanned files, errors, and
+warnings is printed. The summary is printed to stderr if a format other than
+pretty is printed to stdout. The report's "summary" object contains the same
+numbers:
+
+	{
+	  "files_scanned": 148,
+	  "files_with_issues": 7,
+	  "errors": 3,
+	  "warnings": 11,
+	  "duration_ms": 1200
+	}
+
+If --format is not given, pretty is used when stdout is a terminal and json
+otherwise.
+
+The pretty format is colorized when stdout is a terminal and the NO_COLOR
+environment variable is not set. Use --color=always to colorize output that is
+not written to a terminal (e.g., in CI systems that render ANSI colors) and
+--no-color or --color=never to disable color. Other formats are never
+colorized.
+
+With --output, the report is written to the given file instead of stdout
+("-" writes to stdout). The file is replaced atomically once the report is
+complete. The issues are still printed to stdout in the pretty format so that
+the console shows the results of the run. If --output is given without
+--format, the file is written in the json format.
+
+With --quiet, nothing except errors is printed. The report is still written
+to the --output file (if any) and the exit status is the same as without
+--quiet.
+
+The report's "termination" object records whether the run completed. If
+evaluation exceeds the --timeout or is interrupted, the partial report is still
+printed with the reason and the number of modules left unevaluated.
+
+With --max-violations, at most the given number of issues are reported. The
+most severe issues are kept and the report contains "truncated": true and the
+"total_issues" that were found. The summary and the exit status are based on
+all issues that were found.
+
+Issues with the "warn" or "warning" severity are warnings and are reported
+with the "warn" severity. Issues with the "info" severity are informational.
+Issues without a severity or with the "error" severity are errors. Unknown
+severities are reported as errors and listed in the report's "diagnostics".
+The severity set in an issue takes precedence over the severity implied by the
+"warn" and "info" rules. By default, only errors cause the command to fail.
+Use --fail-on warn or --fail-on info to fail on less severe issues as well.
+All issues are reported regardless of --fail-on. The report's "exit_reason"
+explains why the command failed (or did not fail).
+
+The command exits with one of the following statuses:
+
+	0    no issues at or above the --fail-on severity were found
+	1    one or more issues at or above the --fail-on severity were found
+	     (the report is still printed)
+	2    the policies or lint rules could not be loaded, compiled, or
+	     evaluated, or the report could not be written
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			params.format = outputFormat.String()
+			params.color = colorMode.String()
+			params.failOn = failOn.String()
+			os.Exit(opaLint(args, params))
+		},
+	}
+
+	lintCommand.Flags().VarP(outputFormat, "format", "f", "set output format (default: pretty if stdout is a terminal, otherwise json)")
+	lintCommand.Flags().StringVarP(&params.output, "output", "o", "", "write the report to a file instead of stdout (\"-\" for stdout)")
+	lintCommand.Flags().VarP(failOn, "fail-on", "", "set the lowest severity of issues that cause a non-zero exit status (default: error)")
+	lintCommand.Flags().IntVarP(&params.maxIssues, "max-violations", "", 0, "set the maximum number of issues to report (0 for no limit)")
+	lintCommand.Flags().BoolVarP(&params.quiet, "quiet", "q", false, "do not print anything except errors (the exit code is unchanged)")
+	lintCommand.Flags().VarP(colorMode, "color", "", "colorize the pretty format (default: auto)")
+	lintCommand.Flags().BoolVarP(&params.noColor, "no-color", "", false, "do not colorize the pretty format (same as --color=never)")
+	lintCommand.Flags().StringVarP(&params.suiteName, "junit-suite-name", "", "", "set the test suite name reported by the junit format (default: top-level directory)")
+	lintCommand.Flags().IntVarP(&params.mdDetails, "markdown-details", "", linter.DefaultMarkdownDetailsThreshold, "set the number of issues above which the markdown format groups issues in collapsible sections")
+	lintCommand.Flags().IntVarP(&params.mdMaxSize, "markdown-max-size", "", linter.DefaultMarkdownMaxSize, "set the maximum size in bytes of the markdown format output")
+	lintCommand.Flags().StringVarP(&params.template, "template", "", "", "set the template used by the go-template format")
+	lintCommand.Flags().StringVarP(&params.tmplFile, "template-file", "", "", "set the file containing the template used by the go-template format")
+	lintCommand.Flags().StringArrayVarP(&params.queries, "query", "", []string{linter.DefaultQuery}, "set the query that produces lint violations (can be repeated)")
+	lintCommand.Flags().BoolVarP(&params.discover, "auto-discover", "", true, "evaluate the deny rule of the only package with lint rules if the query is undefined")
+	lintCommand.Flags().DurationVarP(&params.timeout, "timeout", "t", linter.DefaultTimeout, "set the maximum amount of time lint evaluation may take (0 for no limit)")
+	lintCommand.Flags().BoolVarP(&params.jsonc, "jsonc", "", false, "allow comments in JSON data files")
+	lintCommand.Flags().BoolVarP(&params.printConfigs, "print-lint-config", "", false, "print the lint settings that apply to each file and exit")
+	lintCommand.Flags().BoolVarP(&params.printParsed, "print-parsed", "", false, "print the input document provided to lint rules and exit")
+	lintCommand.Flags().StringArrayVarP(&params.disabled, "disable-builtin", "", []string{}, "disable a built-in check, e.g., "+linter.BuiltinConflictingDefault)
+	lintCommand.Flags().StringArrayVarP(&params.enable, "enable-rule", "", []string{}, "set rule id glob pattern to report issues of, e.g., 'style/*' (can be repeated)")
+	lintCommand.Flags().StringArrayVarP(&params.disable, "disable-rule", "", []string{}, "set rule id glob pattern to drop issues of, e.g., 'style/*' (can be repeated)")
+	lintCommand.Flags().StringArrayVarP(&params.severity, "severity", "", []string{}, "set the severity of the issues of a rule, e.g., no-todo-comments=warn (can be repeated)")
+	lintCommand.Flags().StringVarP(&params.lintConfig, "lint-config", "", "", "set the file to read lint settings from (default: "+linter.LintConfigFile+" if it exists)")
+	lintCommand.Flags().BoolVarP(&params.verbose, "verbose", "v", false, "print notes about the lint settings, e.g., severity overrides and ignore comments that did not match any issues")
+	lintCommand.Flags().StringArrayVarP(&params.rules, "rules", "", []string{}, "set file or directory to load lint rules from")
+	lintCommand.Flags().BoolVarP(&params.noDefaults, "no-default-rules", "", false, "do not load the default lint rules")
+	lintCommand.Flags().BoolVarP(&params.printRules, "print-default-rules", "", false, "print the default lint rules and exit")
+	lintCommand.Flags().BoolVarP(&params.configs, "include-config", "", false, "lint OPA configuration files named config.yaml or config.yml")
+	lintCommand.Flags().StringArrayVarP(&params.configFiles, "config-file", "", []string{}, "set OPA configuration file to lint (implies --include-config)")
+	lintCommand.Flags().BoolVarP(&params.showLine, "show-line", "", false, "include the offending source line in each issue")
+	lintCommand.Flags().StringVarP(&params.filter, "filter", "", "", "set regular expression that file paths must match to be linted")
+	lintCommand.Flags().StringArrayVarP(&params.ignore, "ignore", "", []string{}, "set file and directory glob patterns to exclude from linting")
+	lintCommand.Flags().StringVarP(&params.sample, "sample", "", "", "lint a sample of the modules, e.g., 10%")
+	lintCommand.Flags().IntVarP(&params.sampleCount, "sample-count", "", 0, "lint a sample of N modules")
+	lintCommand.Flags().StringVarP(&params.sampleSeed, "sample-seed", "", "", "set the seed used to select sampled modules (default: today's date)")
+
+	usageTemplate := `Usage:
+  {{.UseLine}} [flags] [files]
+
+Flags:
+{{.LocalFlags.FlagUsages | trimRightSpace}}
+`
+
+	lintCommand.SetUsageTemplate(usageTemplate)
+
+	RootCommand.AddCommand(lintCommand)
+}
+
+func opaLint(args []string, params lintCommandParams) int {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// In quiet mode, only errors are printed. Reports written with --output
+	// are not affected.
+	var out, summaryOut io.Writer = os.Stdout, os.Stderr
+
+	if params.quiet {
+		out, summaryOut = ioutil.Discard, ioutil.Discard
+	}
+
+	if params.printRules {
+		fmt.Fprint(out, linter.DefaultRules)
+		return lintExitOK
+	}
+
+	tmpl, err := newLintTemplate(params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	sample, err := newLintSample(params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	if err := checkLintOutput(params.output); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	severities, err := lintSeverities(params.severity)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	var configs map[string][]byte
+
+	opts := runtime.LoadOptions{
+		MultiDocumentYAML: true,
+		JSONC:             params.jsonc,
+	}
+
+	if params.configs || len(params.configFiles) > 0 {
+		configs = map[string][]byte{}
+	}
+
+	var lintConfigFiles []string
+
+	// Lint settings are never loaded as data.
+	opts.Skip = func(path string) bool {
+		if filepath.Base(path) == linter.LintConfigFile {
+			lintConfigFiles = append(lintConfigFiles, path)
+			return true
+		}
+		if configs == nil || !isLintConfigFile(path, params.configFiles) {
+			return false
+		}
+		configs[path] = nil
+		return true
+	}
+
+	documents, loaded, err := runtime.LoadPathsWithOptions(args, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	lintConfigs, lintConfigNotes, err := loadLintConfigs(params.lintConfig, lintConfigFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	if params.verbose {
+		for _, note := range lintConfigNotes {
+			fmt.Fprintln(summaryOut, "note:", note)
+		}
+	}
+
+	rulesOpts := opts
+	rulesOpts.Skip = nil
+
+	rules, err := loadLintRules(params.rules, params.queries, params.discover, documents, rulesOpts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	if len(rules) == 0 && !params.noDefaults && !hasAnyLintRules(loaded, params.queries) {
+		rules, err = linter.DefaultLintModules()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+	}
+
+	for _, path := range params.configFiles {
+		configs[path] = nil
+	}
+
+	for path := range configs {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+		configs[path] = bs
+	}
+
+	store := storage.New(storage.InMemoryWithJSONConfig(documents))
+
+	if err := store.Open(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	defer store.Close(ctx, txn)
+
+	modules := map[string]*ast.Module{}
+	sources := map[string][]byte{}
+
+	for id, module := range loaded {
+		modules[id] = module.Parsed
+		sources[id] = module.Raw
+	}
+
+	runner := linter.New().
+		SetOutput(out).
+		SetSuiteName(params.suiteName).
+		SetTemplate(tmpl).
+		SetMarkdownOptions(linter.MarkdownOptions{
+			DetailsThreshold: params.mdDetails,
+			MaxSize:          params.mdMaxSize,
+		}).
+		SetModules(modules).
+		SetLintModules(rules).
+		SetSources(sources).
+		SetConfigs(configs).
+		EnableFailureLine(params.showLine).
+		SetStore(store).
+		EnableAutoDiscover(params.discover).
+		SetTimeout(params.timeout).
+		SetFailOn(params.failOn).
+		SetMaxIssues(params.maxIssues).
+		SetEnabledRules(params.enable).
+		SetDisabledRules(params.disable).
+		SetSeverities(severities).
+		SetLintConfigs(lintConfigs).
+		SetIgnore(params.ignore).
+		Filter(params.filter).
+		SetSample(sample)
+
+	// A single query is set with SetQuery so that it can be discovered.
+	if len(params.queries) == 1 {
+		runner.SetQuery(params.queries[0])
+	} else {
+		runner.SetQueries(params.queries)
+	}
+
+	for _, id := range params.disabled {
+		runner.DisableBuiltin(id)
+	}
+
+	if err := runner.Compile(ctx); err != nil {
+		if err, ok := err.(*linter.DiscoveryError); ok {
+			fmt.Fprintln(os.Stderr, "error:", lintDiscoveryMessage(err))
+			return lintExitError
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	if len(params.queries) == 1 && runner.Query() != params.queries[0] {
+		fmt.Fprintf(summaryOut, "%v is undefined, using %v\n", params.queries[0], runner.Query())
+	}
+
+	if params.printConfigs {
+		if err := runner.PrintLintConfigs(); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+		return lintExitOK
+	}
+
+	if params.printParsed {
+		if err := runner.PrintParsed(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+		return lintExitOK
+	}
+
+	report, lintErr := runner.Lint(ctx, txn)
+	if report == nil {
+		fmt.Fprintln(os.Stderr, "error:", lintErr)
+		return lintExitError
+	}
+
+	for _, diag := range report.Diagnostics {
+		fmt.Fprintln(summaryOut, "warning:", diag)
+	}
+
+	if params.verbose {
+		for _, note := range report.Notes {
+			fmt.Fprintln(summaryOut, "note:", note)
+		}
+	}
+
+	format := params.format
+
+	if params.output == "" || params.output == "-" {
+		if format == "" {
+			format = linter.FormatJSON
+			if logrus.IsTerminal(out) {
+				format = linter.FormatPretty
+			}
+		}
+		runner.EnableColor(useLintColor(params, logrus.IsTerminal(out)))
+		if err := runner.PrintReport(report, format); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+		printLintOmitted(summaryOut, report, format)
+		// Machine formats may be piped to other programs so the summary
+		// is printed to stderr instead.
+		if format != linter.FormatPretty {
+			runner.SetOutput(summaryOut)
+		}
+	} else {
+		if format == "" {
+			format = linter.FormatJSON
+		}
+		err := writeLintReport(params.output, func(w io.Writer) error {
+			return runner.SetOutput(w).PrintReport(report, format)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+		printLintOmitted(summaryOut, report, format)
+		// The report file is usually kept as an artifact so the issues are
+		// also printed for the job log.
+		runner.EnableColor(useLintColor(params, logrus.IsTerminal(out)))
+		if err := runner.SetOutput(out).PrintReport(report, linter.FormatPretty); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return lintExitError
+		}
+	}
+
+	if err := runner.PrintSummary(report); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return lintExitError
+	}
+
+	// A partial report is printed so that callers can see how far the run
+	// got, but the run itself is still treated as a failure.
+	if lintErr != nil {
+		if report.Termination.Reason == linter.TerminationInterrupted {
+			fmt.Fprintf(os.Stderr, "error: %v: %v\n", report.ExitReason, lintErr)
+		} else {
+			fmt.Fprintln(os.Stderr, "error:", lintErr)
+		}
+		return lintExitError
+	}
+
+	if report.Summary.Failures > 0 {
+		return lintExitViolations
+	}
+
+	return lintExitOK
+}
+
+// lintSeverities returns the severity overrides set with --severity.
+func lintSeverities(flags []string) (map[string]string, error) {
+
+	severities := map[string]string{}
+
+	for _, s := range flags {
+		i := strings.LastIndex(s, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid --severity %q: must be rule=level", s)
+		}
+		severities[s[:i]] = s[i+1:]
+	}
+
+	return severities, nil
+}
+
+// loadLintConfigs returns the lint settings keyed by the directory they apply
+// to. The settings in root apply to all files. If root is empty, the
+// LintConfigFile in the working directory is used if it exists. The settings
+// in found apply to the files under their directories. If a settings file is
+// reachable through several paths (e.g., a symlinked directory), it is only
+// applied once per file. The returned notes describe the files used.
+func loadLintConfigs(root string, found []string) (map[string]*linter.LintConfig, []string, error) {
+
+	if root == "" {
+		if _, err := os.Stat(linter.LintConfigFile); err == nil {
+			root = linter.LintConfigFile
+		}
+	}
+
+	type entry struct {
+		dir  string
+		path string
+	}
+
+	entries := []entry{}
+
+	if root != "" {
+		entries = append(entries, entry{"", root})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if len(found[i]) != len(found[j]) {
+			return len(found[i]) < len(found[j])
+		}
+		return found[i] < found[j]
+	})
+
+	for _, path := range found {
+		entries = append(entries, entry{filepath.Dir(path), path})
+	}
+
+	configs := map[string]*linter.LintConfig{}
+	applied := map[string]string{} // directory -> real path of its settings
+	notes := []string{}
+
+	for _, e := range entries {
+
+		real, err := filepath.EvalSymlinks(e.path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if dup := lintConfigApplied(applied, e.dir, real); dup != "" {
+			notes = append(notes, fmt.Sprintf("skipping lint settings %v: already applied by the settings of %v", e.path, dup))
+			continue
+		}
+
+		bs, err := ioutil.ReadFile(e.path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		config, err := linter.ParseLintConfig(e.path, bs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		configs[e.dir] = config
+		applied[e.dir] = real
+		notes = append(notes, fmt.Sprintf("using lint settings %v", e.path))
+	}
+
+	return configs, notes, nil
+}
+
+// lintConfigApplied returns the directory whose settings were read from the
+// file real and apply to dir as well. If there is no such directory, the
+// empty string is returned.
+func lintConfigApplied(applied map[string]string, dir, real string) string {
+
+	dirs := make([]string, 0, len(applied))
+
+	for d := range applied {
+		dirs = append(dirs, d)
+	}
+
+	sort.Strings(dirs)
+
+	for _, d := range dirs {
+		if applied[d] != real {
+			continue
+		}
+		if d == "" || (d == "." && !filepath.IsAbs(dir)) || strings.HasPrefix(filepath.ToSlash(dir)+"/", filepath.ToSlash(d)+"/") {
+			if d == "" {
+				return "."
+			}
+			return d
+		}
+	}
+
+	return ""
+}
+
+// printLintOmitted prints a warning to w if report contains issues that are
+// omitted in format, i.e., issues without a file in the gitlab format.
+func printLintOmitted(w io.Writer, report *linter.Report, format string) {
+
+	if format != linter.FormatGitLab {
+		return
+	}
+
+	n := 0
+
+	for _, issue := range report.Issues {
+		if issue.File == "" {
+			n++
+		}
+	}
+
+	switch {
+	case n == 1:
+		fmt.Fprintln(w, "warning: 1 issue without a file is omitted from the gitlab report")
+	case n > 1:
+		fmt.Fprintf(w, "warning: %d issues without a file are omitted from the gitlab report\n", n)
+	}
+}
+
+// loadLintRules returns the lint rules contained in paths. Data documents
+// contained in paths are merged into documents. Each path must contain a
+// package under the namespace of one of the queries that are references. If
+// discover is true, a package that defines "deny" or "warn" rules is accepted
+// as well.
+func loadLintRules(paths []string, queries []string, discover bool, documents map[string]interface{}, opts runtime.LoadOptions) (map[string]*ast.Module, error) {
+
+	rules := map[string]*ast.Module{}
+
+	for _, path := range paths {
+
+		docs, loaded, err := runtime.LoadPathsWithOptions([]string{path}, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, doc := range docs {
+			if _, ok := documents[key]; ok {
+				return nil, fmt.Errorf("%v: data conflicts with data loaded from other files: %v", path, key)
+			}
+			documents[key] = doc
+		}
+
+		parsed := map[string]*ast.Module{}
+
+		for id, module := range loaded {
+			rules[id] = module.Parsed
+			parsed[id] = module.Parsed
+		}
+
+		if discover && len(linter.LintPackages(parsed)) > 0 {
+			continue
+		}
+
+		if !hasAnyLintRules(loaded, queries) {
+			return nil, fmt.Errorf("%v: no lint rules found under %v", path, lintNamespaces(queries))
+		}
+	}
+
+	return rules, nil
+}
+
+// lintDiscoveryMessage returns a description of err that explains how to
+// select the lint rules.
+func lintDiscoveryMessage(err *linter.DiscoveryError) string {
+
+	if len(err.Candidates) == 1 {
+		return fmt.Sprintf("lint query %v is undefined but lint rules were found in %v: pass --query %v.deny or rename the package", err.Query, err.Candidates[0], err.Candidates[0])
+	}
+
+	return fmt.Sprintf("lint query %v is undefined but lint rules were found in %v: pass --query to select the rules", err.Query, strings.Join(err.Candidates, ", "))
+}
+
+// lintNamespace returns the package namespace of the lint query. If the query
+// is not a reference, nil is returned.
+func lintNamespace(query string) ast.Ref {
+	ref, err := ast.ParseRef(query)
+	if err != nil || len(ref) <= 1 {
+		return nil
+	}
+	return ref[:len(ref)-1]
+}
+
+// lintNamespaces returns the comma-separated package namespaces of queries.
+func lintNamespaces(queries []string) string {
+	namespaces := []string{}
+	for _, query := range queries {
+		if namespace := lintNamespace(query); namespace != nil {
+			namespaces = append(namespaces, namespace.String())
+		}
+	}
+	return strings.Join(namespaces, ", ")
+}
+
+// hasAnyLintRules returns true if one of the modules belongs to a package
+// under the namespace of one of queries. If none of the queries is a
+// reference, true is returned.
+func hasAnyLintRules(modules map[string]*runtime.LoadedModule, queries []string) bool {
+	refs := false
+	for _, query := range queries {
+		if namespace := lintNamespace(query); namespace != nil {
+			refs = true
+			if hasLintRules(modules, namespace) {
+				return true
+			}
+		}
+	}
+	return !refs
+}
+
+// hasLintRules returns true if one of the modules belongs to a package under
+// namespace.
+func hasLintRules(modules map[string]*runtime.LoadedModule, namespace ast.Ref) bool {
+	for _, module := range modules {
+		if module.Parsed.Package.Path.HasPrefix(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLintConfigFile returns true if path refers to an OPA configuration file,
+// i.e., one of the explicitly provided files or a file named config.yaml or
+// config.yml.
+func isLintConfigFile(path string, files []string) bool {
+	for _, file := range files {
+		if filepath.Clean(file) == filepath.Clean(path) {
+			return true
+		}
+	}
+	switch filepath.Base(path) {
+	case "config.yaml", "config.yml":
+		return true
+	}
+	return false
+}
+
+// useLintColor returns true if the pretty format printed to a terminal (or
+// not, if terminal is false) should be colorized. In auto mode, color is used
+// on terminals unless the NO_COLOR environment variable is set.
+func useLintColor(params lintCommandParams, terminal bool) bool {
+
+	if params.noColor {
+		return false
+	}
+
+	switch params.color {
+	case lintColorAlways:
+		return true
+	case lintColorNever:
+		return false
+	}
+
+	return terminal && os.Getenv("NO_COLOR") == ""
+}
+
+// checkLintOutput returns an error if the report cannot be written to path
+// because its directory does not exist.
+func checkLintOutput(path string) error {
+
+	if path == "" || path == "-" {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("cannot write report to %v: directory %v does not exist", path, dir)
+		}
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("cannot write report to %v: %v is not a directory", path, dir)
+	}
+
+	return nil
+}
+
+// writeLintReport calls f with a temporary file in the directory of path and
+// renames the file to path once f succeeds. The file at path is therefore
+// either left untouched or replaced by the complete report.
+func writeLintReport(path string, f func(io.Writer) error) error {
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+
+	err = f(tmp)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// newLintTemplate returns the template given by --template or
+// --template-file.
+func newLintTemplate(params lintCommandParams) (string, error) {
+
+	if params.template != "" && params.tmplFile != "" {
+		return "", fmt.Errorf("--template and --template-file cannot be used together")
+	}
+
+	tmpl := params.template
+
+	if params.tmplFile != "" {
+		bs, err := ioutil.ReadFile(params.tmplFile)
+		if err != nil {
+			return "", err
+		}
+		tmpl = string(bs)
+	}
+
+	if params.format == linter.FormatTemplate && tmpl == "" {
+		return "", fmt.Errorf("--format %v requires --template or --template-file", linter.FormatTemplate)
+	}
+
+	return tmpl, nil
+}
+
+func newLintSample(params lintCommandParams) (*linter.Sample, error) {
+
+	if params.sample == "" && params.sampleCount == 0 {
+		return nil, nil
+	}
+
+	if params.sample != "" && params.sampleCount != 0 {
+		return nil, fmt.Errorf("--sample and --sample-count cannot be used together")
+	}
+
+	sample := &linter.Sample{
+		Count: params.sampleCount,
+		Seed:  params.sampleSeed,
+	}
+
+	if sample.Seed == "" {
+		sample.Seed = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if params.sample != "" {
+		s := strings.TrimSpace(params.sample)
+		percent := strings.HasSuffix(s, "%")
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("--sample must be a percentage (e.g., 10%%) or a fraction (e.g., 0.1): %v", params.sample)
+		}
+		if percent {
+			f /= 100
+		}
+		sample.Fraction = f
+	}
+
+	return sample, nil
+}
@@ -0,0 +1,1008 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/linter"
+)
+
+const testLintRules = `package system.lint
+
+deny[{"message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}`
+
+func TestLintExitCode(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		files    map[string]string
+		expected int
+	}{
+		{
+			note: "clean",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+bar = true`,
+				"/lint/rules.rego": testLintRules,
+			},
+			expected: lintExitOK,
+		},
+		{
+			note: "violations",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/lint/rules.rego": testLintRules,
+			},
+			expected: lintExitViolations,
+		},
+		{
+			note: "compile error",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = x`,
+				"/lint/rules.rego": testLintRules,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "undefined built-in in lint rules",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/lint/rules.rego": `package system.lint
+deny[{"message": msg}] { input.modules[_]; msg = no_such_builtin("x") }`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "eval error in lint rules",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true
+bar = true`,
+				"/lint/rules.rego": `package system.lint
+name = n { input.modules[_].rules[_].head.name = n }
+deny[{"message": name}] { true }`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "invalid violation",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/lint/rules.rego": `package system.lint
+deny[{"msg": "x"}] { true }`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "load error",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = `,
+				"/lint/rules.rego": testLintRules,
+			},
+			expected: lintExitError,
+		},
+	}
+
+	for _, tc := range tests {
+		withTempFS(t, tc.files, func(rootDir string) {
+			args := []string{
+				filepath.Join(rootDir, "policies"),
+				filepath.Join(rootDir, "lint"),
+			}
+			params := lintCommandParams{queries: []string{linter.DefaultQuery}}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("%v: expected exit code %v but got %v", tc.note, tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestLintRules(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		files    map[string]string
+		expected int
+	}{
+		{
+			note: "clean",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+bar = true`,
+				"/rules/rules.rego": testLintRules + `
+foo = true`,
+			},
+			expected: lintExitOK,
+		},
+		{
+			note: "violations",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/rules/rules.rego": testLintRules,
+			},
+			expected: lintExitViolations,
+		},
+		{
+			note: "no lint rules",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/rules/rules.rego": `package other
+deny[{"message": "x"}] { true }`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "lint rules parse error",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/rules/rules.rego": `package system.lint
+deny[{"message": "x"}] {`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "lint rules compile error",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+				"/rules/rules.rego": `package system.lint
+deny[{"message": x}] { true }`,
+			},
+			expected: lintExitError,
+		},
+		{
+			note: "lint rules missing",
+			files: map[string]string{
+				"/policies/a.rego": `package a
+foo = true`,
+			},
+			expected: lintExitError,
+		},
+	}
+
+	for _, tc := range tests {
+		withTempFS(t, tc.files, func(rootDir string) {
+			args := []string{filepath.Join(rootDir, "policies")}
+			params := lintCommandParams{
+				queries: []string{linter.DefaultQuery},
+				rules:   []string{filepath.Join(rootDir, "rules")},
+			}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("%v: expected exit code %v but got %v", tc.note, tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestLintExitCodeReport(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/rules/rules.rego": testLintRules,
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		stdout := os.Stdout
+		defer func() {
+			os.Stdout = stdout
+		}()
+
+		var err error
+
+		if os.Stdout, err = ioutil.TempFile(rootDir, "stdout"); err != nil {
+			t.Fatal(err)
+		}
+
+		params := lintCommandParams{
+			queries: []string{linter.DefaultQuery},
+			rules:   []string{filepath.Join(rootDir, "rules")},
+			format:  linter.FormatJSON,
+		}
+
+		if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != lintExitViolations {
+			t.Fatalf("Expected exit code %v but got %v", lintExitViolations, code)
+		}
+
+		bs, err := ioutil.ReadFile(os.Stdout.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var report linter.Report
+
+		if err := json.Unmarshal(bs, &report); err != nil || len(report.Issues) != 1 {
+			t.Fatalf("Expected JSON report with one issue but got %q (err: %v)", bs, err)
+		}
+	})
+}
+
+func TestLintFailOn(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/policies/b.rego": `package b
+bar = true`,
+		"/rules/rules.rego": `package system.lint
+warn[{"message": "x"}] { input.modules[_].rules[_].head.name = "foo" }
+info[{"message": "y"}] { input.modules[_].rules[_].head.name = "bar" }`,
+	}
+
+	tests := []struct {
+		args     []string
+		failOn   string
+		expected int
+	}{
+		{[]string{"a.rego"}, "", lintExitOK},
+		{[]string{"a.rego"}, linter.SeverityError, lintExitOK},
+		{[]string{"a.rego"}, linter.SeverityWarn, lintExitViolations},
+		{[]string{"a.rego"}, linter.SeverityInfo, lintExitViolations},
+		{[]string{"b.rego"}, linter.SeverityWarn, lintExitOK},
+		{[]string{"b.rego"}, linter.SeverityInfo, lintExitViolations},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+			args := []string{filepath.Join(rootDir, "policies", tc.args[0])}
+			params := lintCommandParams{
+				queries: []string{linter.DefaultQuery},
+				rules:   []string{filepath.Join(rootDir, "rules")},
+				failOn:  tc.failOn,
+			}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("%v with --fail-on %q: expected exit code %v but got %v", tc.args, tc.failOn, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestLintEnableDisableRules(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/rules/rules.rego": `package system.lint
+deny[{"id": "style/naming", "message": "x"}] { input.modules[_].rules[_].head.name = "foo" }`,
+	}
+
+	tests := []struct {
+		enable   []string
+		disable  []string
+		expected int
+	}{
+		{nil, nil, lintExitViolations},
+		{nil, []string{"style/*"}, lintExitOK},
+		{nil, []string{"security/*"}, lintExitViolations},
+		{[]string{"security/*"}, nil, lintExitOK},
+		{[]string{"style/naming"}, nil, lintExitViolations},
+		{[]string{"style/*"}, []string{"style/naming"}, lintExitOK},
+		{nil, []string{"[a-"}, lintExitError},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+			args := []string{filepath.Join(rootDir, "policies")}
+			params := lintCommandParams{
+				queries: []string{linter.DefaultQuery},
+				rules:   []string{filepath.Join(rootDir, "rules")},
+				enable:  tc.enable,
+				disable: tc.disable,
+			}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("--enable-rule %v --disable-rule %v: expected exit code %v but got %v", tc.enable, tc.disable, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestLintSeverity(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/settings.yaml": `severity:
+  no-foo: info`,
+		"/rules/rules.rego": `package system.lint
+deny[{"id": "no-foo", "message": "x"}] { input.modules[_].rules[_].head.name = "foo" }`,
+	}
+
+	tests := []struct {
+		severity   []string
+		lintConfig bool
+		failOn     string
+		expected   int
+	}{
+		{nil, false, "", lintExitViolations},
+		{[]string{"no-foo=warn"}, false, "", lintExitOK},
+		{[]string{"no-foo=warn"}, false, linter.SeverityWarn, lintExitViolations},
+		{[]string{"no-foo=info"}, false, linter.SeverityWarn, lintExitOK},
+		{[]string{"typo=warn"}, false, "", lintExitViolations},
+		{nil, true, linter.SeverityWarn, lintExitOK},
+		{nil, true, linter.SeverityInfo, lintExitViolations},
+		{[]string{"no-foo=error"}, true, "", lintExitViolations},
+		{[]string{"no-foo=critical"}, false, "", lintExitError},
+		{[]string{"no-foo"}, false, "", lintExitError},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+			args := []string{filepath.Join(rootDir, "policies")}
+			params := lintCommandParams{
+				queries:  []string{linter.DefaultQuery},
+				rules:    []string{filepath.Join(rootDir, "rules")},
+				severity: tc.severity,
+				failOn:   tc.failOn,
+			}
+			if tc.lintConfig {
+				params.lintConfig = filepath.Join(rootDir, "settings.yaml")
+			}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("--severity %v (settings: %v) with --fail-on %q: expected exit code %v but got %v", tc.severity, tc.lintConfig, tc.failOn, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestLintNestedConfigs(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/.opa-lint.yaml": `severity:
+  no-foo: warn
+  no-bar: warn`,
+		"/policies/platform/a.rego": `package platform
+foo = true`,
+		"/policies/platform/.opa-lint.yaml": `severity:
+  no-foo: error`,
+		"/policies/teams/x/b.rego": `package teams.x
+foo = true
+bar = true`,
+		"/policies/teams/x/.opa-lint.yaml": `disable:
+  - no-bar
+ignore:
+  - gen/*`,
+		"/policies/teams/x/gen/c.rego": `package teams.x.gen
+foo = true`,
+		"/rules/rules.rego": `package system.lint
+deny[{"id": "no-foo", "message": "foo", "location": r.location}] { r = input.modules[_].rules[_]; r.head.name = "foo" }
+deny[{"id": "no-bar", "message": "bar", "location": r.location}] { r = input.modules[_].rules[_]; r.head.name = "bar" }`,
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		policies := filepath.Join(rootDir, "policies")
+		params := lintCommandParams{
+			queries: []string{linter.DefaultQuery},
+			rules:   []string{filepath.Join(rootDir, "rules")},
+		}
+
+		params.output = filepath.Join(rootDir, "report.json")
+		params.quiet = true
+
+		if code := opaLint([]string{policies}, params); code != lintExitViolations {
+			t.Fatalf("Expected exit code %v but got %v", lintExitViolations, code)
+		}
+
+		bs, err := ioutil.ReadFile(params.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var report linter.Report
+
+		if err := json.Unmarshal(bs, &report); err != nil {
+			t.Fatal(err)
+		}
+
+		result := map[string]string{}
+
+		for _, issue := range report.Issues {
+			rel, _ := filepath.Rel(policies, issue.File)
+			result[rel+" "+issue.RuleID] = issue.Severity
+		}
+
+		expected := map[string]string{
+			filepath.Join("platform", "a.rego") + " no-foo":   linter.SeverityError,
+			filepath.Join("teams", "x", "b.rego") + " no-foo": linter.SeverityWarn,
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected issues %v but got: %v", expected, result)
+		}
+
+		if report.Summary.Disabled != 1 {
+			t.Errorf("Expected 1 disabled issue but got: %+v", report.Summary)
+		}
+
+		// Settings of parent directories only apply if they are linted.
+		params.output = ""
+
+		if code := opaLint([]string{filepath.Join(policies, "teams")}, params); code != lintExitViolations {
+			t.Errorf("Expected exit code %v but got %v", lintExitViolations, code)
+		}
+
+		configs, notes, err := loadLintConfigs("", []string{
+			filepath.Join(policies, "teams", "x", linter.LintConfigFile),
+			filepath.Join(policies, linter.LintConfigFile),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		runner := linter.New().SetLintConfigs(configs)
+		effective := runner.EffectiveLintConfig(filepath.Join(policies, "teams", "x", "b.rego"))
+		expectedConfig := &linter.LintConfig{
+			Severity: map[string]string{"no-foo": linter.SeverityWarn, "no-bar": linter.SeverityWarn},
+			Disable:  []string{"no-bar"},
+			Ignore:   []string{"gen/*"},
+			Dirs:     []string{policies, filepath.Join(policies, "teams", "x")},
+		}
+
+		if !reflect.DeepEqual(effective, expectedConfig) {
+			t.Errorf("Expected effective settings %+v but got: %+v", expected, effective)
+		}
+
+		if len(notes) != 2 {
+			t.Errorf("Expected a note for each settings file but got: %v", notes)
+		}
+	})
+}
+
+func TestLoadLintConfigsSymlink(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/.opa-lint.yaml": `disable:
+  - no-foo`,
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		policies := filepath.Join(rootDir, "policies")
+		link := filepath.Join(policies, "self")
+
+		if err := os.Symlink(policies, link); err != nil {
+			t.Fatal(err)
+		}
+
+		configs, notes, err := loadLintConfigs("", []string{
+			filepath.Join(policies, linter.LintConfigFile),
+			filepath.Join(link, linter.LintConfigFile),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(configs) != 1 || configs[policies] == nil {
+			t.Fatalf("Expected settings of %v only but got: %v", policies, configs)
+		}
+
+		if len(notes) != 2 || !strings.HasPrefix(notes[1], "skipping lint settings") {
+			t.Fatalf("Expected symlinked settings to be skipped but got: %v", notes)
+		}
+	})
+}
+
+func TestLintAutoDiscover(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/rules/style.rego": `package lint.style
+deny[{"message": "rules must not be named foo"}] { input.modules[_].rules[_].head.name = "foo" }`,
+	}
+
+	tests := []struct {
+		discover bool
+		expected int
+	}{
+		{true, lintExitViolations},
+		{false, lintExitError},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+			params := lintCommandParams{
+				queries:  []string{linter.DefaultQuery},
+				rules:    []string{filepath.Join(rootDir, "rules")},
+				discover: tc.discover,
+			}
+			if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != tc.expected {
+				t.Errorf("--auto-discover=%v: expected exit code %v but got %v", tc.discover, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestLintAdmissionPolicy(t *testing.T) {
+
+	// The policy defines a "deny" rule like most admission policies. It must
+	// not be mistaken for lint rules: the default rules still report the rule
+	// name and custom rules are evaluated without errors.
+	files := map[string]string{
+		"/policies/admission.rego": `package kubernetes.admission
+
+allowedRegistry = "hooli.com/"
+
+deny[msg] {
+	input.request.kind.kind = "Pod"
+	image = input.request.object.spec.containers[_].image
+	not startswith(image, allowedRegistry)
+	msg = "image comes from untrusted registry"
+}`,
+		"/rules/style.rego": `package lint.style
+deny[{"id": "no-camel-case", "message": "rules must not use camel case"}] { input.modules[_].rules[_].head.name = "allowedRegistry" }`,
+	}
+
+	tests := []struct {
+		note     string
+		rules    []string
+		expected string
+	}{
+		{"default rules", nil, "default/rule-name-case"},
+		{"custom rules", []string{"rules"}, "no-camel-case"},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+
+			output := filepath.Join(rootDir, "report.json")
+			params := lintCommandParams{
+				queries:  []string{linter.DefaultQuery},
+				discover: true,
+				format:   linter.FormatJSON,
+				output:   output,
+			}
+
+			for _, path := range tc.rules {
+				params.rules = append(params.rules, filepath.Join(rootDir, path))
+			}
+
+			if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != lintExitViolations {
+				t.Fatalf("%v: expected exit code %v but got %v", tc.note, lintExitViolations, code)
+			}
+
+			bs, err := ioutil.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(bs), tc.expected) {
+				t.Fatalf("%v: expected %v issue but got:\n%s", tc.note, tc.expected, bs)
+			}
+		}
+	})
+}
+
+func TestLintDiscoveryMessage(t *testing.T) {
+
+	err := &linter.DiscoveryError{Query: "data.system.lint.deny", Candidates: []string{"data.lint.style"}}
+	expected := "lint query data.system.lint.deny is undefined but lint rules were found in data.lint.style: pass --query data.lint.style.deny or rename the package"
+
+	if result := lintDiscoveryMessage(err); result != expected {
+		t.Fatalf("Expected %q but got %q", expected, result)
+	}
+
+	err.Candidates = append(err.Candidates, "data.lint.naming")
+	expected = "lint query data.system.lint.deny is undefined but lint rules were found in data.lint.style, data.lint.naming: pass --query to select the rules"
+
+	if result := lintDiscoveryMessage(err); result != expected {
+		t.Fatalf("Expected %q but got %q", expected, result)
+	}
+}
+
+func TestLintQueries(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+bar = true`,
+		"/rules/org.rego": testLintRules,
+		"/rules/team.rego": `package teams.payments.lint
+deny[{"message": "rules must not be named bar"}] { input.modules[_].rules[_].head.name = "bar" }`,
+	}
+
+	tests := []struct {
+		queries  []string
+		expected int
+	}{
+		{[]string{linter.DefaultQuery}, lintExitOK},
+		{[]string{linter.DefaultQuery, "data.teams.payments.lint.deny"}, lintExitViolations},
+		{[]string{"data.teams.payments.lint.deny", "data.missing.lint.deny"}, lintExitViolations},
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+		for _, tc := range tests {
+			params := lintCommandParams{
+				queries: tc.queries,
+				rules:   []string{filepath.Join(rootDir, "rules")},
+			}
+			if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != tc.expected {
+				t.Errorf("%v: expected exit code %v but got %v", tc.queries, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestLintDefaultRules(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a`,
+	}
+
+	tests := []struct {
+		note     string
+		params   lintCommandParams
+		expected int
+	}{
+		{"default rules", lintCommandParams{}, lintExitViolations},
+		{"no default rules", lintCommandParams{noDefaults: true}, lintExitOK},
+	}
+
+	for _, tc := range tests {
+		withTempFS(t, files, func(rootDir string) {
+			args := []string{filepath.Join(rootDir, "policies")}
+			params := tc.params
+			params.queries = []string{linter.DefaultQuery}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("%v: expected exit code %v but got %v", tc.note, tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestLintIncludeConfig(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a`,
+		"/policies/config.yaml": `services:
+  acme:
+    credentials:
+      bearer:
+        token: secret`,
+		"/policies/opa.yaml": `labels:
+  token: secret
+credentials:
+  token: secret`,
+		"/lint/rules.rego": `package system.lint
+deny[{"message": "plaintext credentials", "location": k.location}] {
+	k = input.opa_configs[_].keys[_]
+	k.path[_] = "credentials"
+	k.key = "token"
+}`,
+	}
+
+	tests := []struct {
+		note     string
+		params   lintCommandParams
+		expected int
+	}{
+		{"disabled", lintCommandParams{}, lintExitOK},
+		{"naming convention", lintCommandParams{configs: true}, lintExitViolations},
+		{"explicit file", lintCommandParams{configFiles: []string{"opa.yaml"}}, lintExitViolations},
+	}
+
+	for _, tc := range tests {
+		withTempFS(t, files, func(rootDir string) {
+			args := []string{
+				filepath.Join(rootDir, "policies"),
+				filepath.Join(rootDir, "lint"),
+			}
+			params := tc.params
+			params.queries = []string{linter.DefaultQuery}
+			for i := range params.configFiles {
+				params.configFiles[i] = filepath.Join(rootDir, "policies", params.configFiles[i])
+			}
+			if code := opaLint(args, params); code != tc.expected {
+				t.Errorf("%v: expected exit code %v but got %v", tc.note, tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestLintOutput(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/rules/rules.rego": testLintRules,
+		"/out/report.json":  "old",
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		path := filepath.Join(rootDir, "out", "report.json")
+		params := lintCommandParams{
+			queries: []string{linter.DefaultQuery},
+			rules:   []string{filepath.Join(rootDir, "rules")},
+			output:  path,
+		}
+
+		if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != lintExitViolations {
+			t.Fatalf("Expected exit code %v but got %v", lintExitViolations, code)
+		}
+
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var report linter.Report
+
+		if err := json.Unmarshal(bs, &report); err != nil {
+			t.Fatalf("Expected JSON report but got %q: %v", bs, err)
+		}
+
+		if len(report.Issues) != 1 {
+			t.Fatalf("Expected one issue but got: %+v", report.Issues)
+		}
+
+		entries, err := ioutil.ReadDir(filepath.Join(rootDir, "out"))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Expected only the report in output directory but got: %v (err: %v)", entries, err)
+		}
+
+		params.output = filepath.Join(rootDir, "missing", "report.json")
+
+		if code := opaLint([]string{filepath.Join(rootDir, "policies")}, params); code != lintExitError {
+			t.Fatalf("Expected exit code %v for missing directory but got %v", lintExitError, code)
+		}
+	})
+}
+
+func TestLintQuiet(t *testing.T) {
+
+	files := map[string]string{
+		"/policies/a.rego": `package a
+foo = true`,
+		"/policies/b.rego": `package b
+bar = true`,
+		"/rules/rules.rego": testLintRules,
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		stdout, stderr := os.Stdout, os.Stderr
+		defer func() {
+			os.Stdout, os.Stderr = stdout, stderr
+		}()
+
+		var err error
+
+		if os.Stdout, err = ioutil.TempFile(rootDir, "stdout"); err != nil {
+			t.Fatal(err)
+		}
+
+		if os.Stderr, err = ioutil.TempFile(rootDir, "stderr"); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(rootDir, "report.json")
+		tests := []struct {
+			args     []string
+			params   lintCommandParams
+			expected int
+		}{
+			{[]string{"policies/b.rego"}, lintCommandParams{}, lintExitOK},
+			{[]string{"policies"}, lintCommandParams{}, lintExitViolations},
+			{[]string{"policies"}, lintCommandParams{format: linter.FormatCompact}, lintExitViolations},
+			{[]string{"policies"}, lintCommandParams{output: path}, lintExitViolations},
+			{[]string{"policies"}, lintCommandParams{printParsed: true}, lintExitOK},
+		}
+
+		for _, tc := range tests {
+			for i := range tc.args {
+				tc.args[i] = filepath.Join(rootDir, tc.args[i])
+			}
+			tc.params.queries = []string{linter.DefaultQuery}
+			tc.params.rules = []string{filepath.Join(rootDir, "rules")}
+			tc.params.quiet = true
+			if code := opaLint(tc.args, tc.params); code != tc.expected {
+				t.Errorf("%+v: expected exit code %v but got %v", tc.params, tc.expected, code)
+			}
+		}
+
+		for _, f := range []*os.File{os.Stdout, os.Stderr} {
+			bs, err := ioutil.ReadFile(f.Name())
+			if err != nil || len(bs) != 0 {
+				t.Errorf("Expected no output on %v but got %q (err: %v)", filepath.Base(f.Name()), bs, err)
+			}
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected report to be written: %v", err)
+		}
+	})
+}
+
+func TestWriteLintReport(t *testing.T) {
+
+	files := map[string]string{
+		"/report.json": "old",
+	}
+
+	withTempFS(t, files, func(rootDir string) {
+
+		path := filepath.Join(rootDir, "report.json")
+
+		err := writeLintReport(path, func(w io.Writer) error {
+			fmt.Fprint(w, "partial")
+			return fmt.Errorf("crashed")
+		})
+
+		if err == nil || err.Error() != "crashed" {
+			t.Fatalf("Expected error from writer but got: %v", err)
+		}
+
+		if bs, err := ioutil.ReadFile(path); err != nil || string(bs) != "old" {
+			t.Fatalf("Expected report to be untouched but got %q (err: %v)", bs, err)
+		}
+
+		entries, err := ioutil.ReadDir(rootDir)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Expected temporary file to be removed but got: %v (err: %v)", entries, err)
+		}
+
+		err = writeLintReport(path, func(w io.Writer) error {
+			_, err := fmt.Fprint(w, "new")
+			return err
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if bs, err := ioutil.ReadFile(path); err != nil || string(bs) != "new" {
+			t.Fatalf("Expected report to be replaced but got %q (err: %v)", bs, err)
+		}
+	})
+}
+
+func withTempFS(t *testing.T, files map[string]string, f func(string)) {
+
+	rootDir, err := ioutil.TempDir("", "cmd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(rootDir)
+
+	for path, content := range files {
+		path = filepath.Join(rootDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f(rootDir)
+}
+
+func TestNewLintSample(t *testing.T) {
+
+	tests := []struct {
+		params   lintCommandParams
+		expected *linter.Sample
+		err      bool
+	}{
+		{lintCommandParams{}, nil, false},
+		{lintCommandParams{sample: "10%", sampleSeed: "s"}, &linter.Sample{Fraction: 0.1, Seed: "s"}, false},
+		{lintCommandParams{sample: "0.25", sampleSeed: "s"}, &linter.Sample{Fraction: 0.25, Seed: "s"}, false},
+		{lintCommandParams{sampleCount: 5, sampleSeed: "s"}, &linter.Sample{Count: 5, Seed: "s"}, false},
+		{lintCommandParams{sample: "ten"}, nil, true},
+		{lintCommandParams{sample: "10%", sampleCount: 5}, nil, true},
+	}
+
+	for _, tc := range tests {
+		sample, err := newLintSample(tc.params)
+		if (err != nil) != tc.err {
+			t.Errorf("%+v: unexpected error: %v", tc.params, err)
+		} else if !reflect.DeepEqual(sample, tc.expected) {
+			t.Errorf("%+v: expected %+v but got %+v", tc.params, tc.expected, sample)
+		}
+	}
+
+	sample, err := newLintSample(lintCommandParams{sampleCount: 1})
+	if err != nil || sample.Seed == "" {
+		t.Fatalf("Expected default seed but got: %+v (err: %v)", sample, err)
+	}
+}
+
+func TestUseLintColor(t *testing.T) {
+
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+
+	tests := []struct {
+		params   lintCommandParams
+		terminal bool
+		noColor  string
+		expected bool
+	}{
+		{lintCommandParams{color: lintColorAuto}, true, "", true},
+		{lintCommandParams{color: lintColorAuto}, false, "", false},
+		{lintCommandParams{color: lintColorAuto}, true, "1", false},
+		{lintCommandParams{color: lintColorAlways}, false, "1", true},
+		{lintCommandParams{color: lintColorNever}, true, "", false},
+		{lintCommandParams{color: lintColorAuto, noColor: true}, true, "", false},
+		{lintCommandParams{color: lintColorAlways, noColor: true}, true, "", false},
+	}
+
+	for _, tc := range tests {
+		os.Setenv("NO_COLOR", tc.noColor)
+		if result := useLintColor(tc.params, tc.terminal); result != tc.expected {
+			t.Errorf("%+v (terminal: %v, NO_COLOR: %q): expected %v but got %v", tc.params, tc.terminal, tc.noColor, tc.expected, result)
+		}
+	}
+}
+
+func TestNewLintTemplate(t *testing.T) {
+
+	tests := []struct {
+		params   lintCommandParams
+		expected string
+		err      bool
+	}{
+		{lintCommandParams{format: "json"}, "", false},
+		{lintCommandParams{format: "go-template", template: "{{.Summary.Issues}}"}, "{{.Summary.Issues}}", false},
+		{lintCommandParams{format: "go-template"}, "", true},
+		{lintCommandParams{template: "a", tmplFile: "b"}, "", true},
+		{lintCommandParams{tmplFile: "does-not-exist.tmpl"}, "", true},
+	}
+
+	for _, tc := range tests {
+		tmpl, err := newLintTemplate(tc.params)
+		if (err != nil) != tc.err {
+			t.Errorf("%+v: unexpected error: %v", tc.params, err)
+		} else if tmpl != tc.expected {
+			t.Errorf("%+v: expected %q but got %q", tc.params, tc.expected, tmpl)
+		}
+	}
+}
+
+func TestPrintLintOmitted(t *testing.T) {
+
+	report := &linter.Report{
+		Issues: []linter.Issue{
+			{Message: "policy set is too small"},
+			{File: "a.rego", Message: "bad"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	printLintOmitted(buf, report, linter.FormatGitLab)
+
+	if expected := "warning: 1 issue without a file is omitted from the gitlab report\n"; buf.String() != expected {
+		t.Fatalf("Expected %q but got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	printLintOmitted(buf, report, linter.FormatJSON)
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no warning but got %q", buf.String())
+	}
+}
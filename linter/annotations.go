@@ -0,0 +1,328 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Annotation scopes that lint ignores can be declared for.
+const (
+	scopeRule        = "rule"        // the rule that follows the annotation
+	scopeDocument    = "document"    // all rules of the package with the same name
+	scopePackage     = "package"     // the file that declares the package
+	scopeSubpackages = "subpackages" // all files of the package and its subpackages
+)
+
+// annotationIgnore is a METADATA block that declares lint ignores, e.g.:
+//
+//	# METADATA
+//	# scope: package
+//	# custom:
+//	#   lint:
+//	#     ignore: ["no-foo", "style/*"]
+//	package a
+//
+// The parser does not retain comments so METADATA blocks are read from the
+// source of the modules. A block applies to the package or rule that
+// immediately follows it. The scope defaults to scopePackage for packages and
+// to scopeRule for rules.
+type annotationIgnore struct {
+	file  string
+	row   int
+	scope string
+	rules rulePatterns
+	pkg   string
+	name  string
+	start int
+	end   int
+	used  bool
+}
+
+func (a *annotationIgnore) String() string {
+	return fmt.Sprintf("METADATA lint ignore %v (%v scope)", strings.Join(a.rules, ","), a.scope)
+}
+
+// ruleSpan is the range of rows [start, end] that a rule occupies in a file.
+type ruleSpan struct {
+	name  string
+	start int
+	end   int
+}
+
+// annotationIndex contains the lint ignores declared in the METADATA blocks
+// of a set of modules along with the packages and rule spans needed to map
+// the scopes of the ignores to issue locations.
+type annotationIndex struct {
+	ignores []*annotationIgnore
+	pkgs    map[string]string
+	spans   map[string][]ruleSpan
+}
+
+// newAnnotationIndex returns the index of the METADATA blocks contained in
+// sources, which are keyed by the file names of modules. Blocks of targets
+// that cannot be parsed are described by the returned diagnostics.
+func newAnnotationIndex(modules, targets map[string]*ast.Module, sources map[string][]byte) (*annotationIndex, []string) {
+
+	index := &annotationIndex{
+		pkgs:  map[string]string{},
+		spans: map[string][]ruleSpan{},
+	}
+
+	files := make([]string, 0, len(modules))
+
+	for file := range modules {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	var diagnostics []string
+
+	for _, file := range files {
+
+		module := modules[file]
+		src := sources[file]
+		lines := strings.Split(string(src), "\n")
+
+		index.pkgs[file] = module.Package.Path.String()
+		index.spans[file] = moduleRuleSpans(module, len(lines))
+
+		for _, block := range metadataBlocks(lines) {
+
+			a, err := index.parse(file, module, block)
+			if err != nil {
+				if _, ok := targets[file]; !ok {
+					continue
+				}
+				diagnostics = append(diagnostics, fmt.Sprintf("%v:%d: invalid METADATA: %v", file, block.row, err))
+				continue
+			}
+
+			if a != nil {
+				index.ignores = append(index.ignores, a)
+			}
+		}
+	}
+
+	return index, diagnostics
+}
+
+// moduleRuleSpans returns the spans of the rules of module. A rule extends to
+// the row before the next rule or to the end of a file with n rows.
+func moduleRuleSpans(module *ast.Module, n int) []ruleSpan {
+
+	spans := make([]ruleSpan, 0, len(module.Rules))
+
+	for _, rule := range module.Rules {
+		if rule.Head.Location != nil {
+			spans = append(spans, ruleSpan{name: string(rule.Head.Name), start: rule.Head.Location.Row})
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	for i := range spans {
+		spans[i].end = n
+		if i+1 < len(spans) {
+			spans[i].end = spans[i+1].start - 1
+		}
+	}
+
+	return spans
+}
+
+// metadataBlock is a METADATA comment block at row followed by target.
+type metadataBlock struct {
+	row    int
+	target int
+	text   string
+}
+
+// metadataBlocks returns the METADATA comment blocks contained in lines.
+func metadataBlocks(lines []string) []metadataBlock {
+
+	var blocks []metadataBlock
+
+	for i := 0; i < len(lines); i++ {
+
+		if line := strings.TrimSpace(lines[i]); !strings.HasPrefix(line, "#") || strings.TrimSpace(line[1:]) != "METADATA" {
+			continue
+		}
+
+		block := metadataBlock{row: i + 1}
+		text := []string{}
+		j := i + 1
+
+		for ; j < len(lines); j++ {
+			line := strings.TrimSpace(lines[j])
+			if !strings.HasPrefix(line, "#") {
+				break
+			}
+			line = strings.TrimRight(strings.TrimLeft(lines[j], " \t"), "\r")
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+			text = append(text, line)
+		}
+
+		block.target = j + 1
+		block.text = strings.Join(text, "\n")
+		blocks = append(blocks, block)
+		i = j - 1
+	}
+
+	return blocks
+}
+
+// parse returns the lint ignore declared in block. If the block does not
+// declare lint ignores or is not followed by a package or rule, nil is
+// returned.
+func (index *annotationIndex) parse(file string, module *ast.Module, block metadataBlock) (*annotationIgnore, error) {
+
+	var metadata struct {
+		Scope  string                 `json:"scope"`
+		Custom map[string]interface{} `json:"custom"`
+	}
+
+	if err := yaml.Unmarshal([]byte(block.text), &metadata); err != nil {
+		return nil, err
+	}
+
+	lint, ok := metadata.Custom["lint"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	value, ok := lint["ignore"]
+	if !ok {
+		return nil, nil
+	}
+
+	a := &annotationIgnore{
+		file:  file,
+		row:   block.row,
+		scope: metadata.Scope,
+		pkg:   index.pkgs[file],
+	}
+
+	switch value := value.(type) {
+	case string:
+		a.rules = rulePatterns{value}
+	case []interface{}:
+		for _, x := range value {
+			s, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("lint ignore must contain strings but got %v", x)
+			}
+			a.rules = append(a.rules, s)
+		}
+	default:
+		return nil, fmt.Errorf("lint ignore must be a string or a list of strings but got %v", value)
+	}
+
+	if err := a.rules.validate(); err != nil {
+		return nil, err
+	}
+
+	isPackage := module.Package.Location != nil && module.Package.Location.Row == block.target
+
+	if !isPackage {
+		for _, span := range index.spans[file] {
+			if span.start == block.target {
+				a.name, a.start, a.end = span.name, span.start, span.end
+			}
+		}
+		if a.name == "" {
+			return nil, nil
+		}
+	}
+
+	switch {
+	case a.scope == "" && isPackage:
+		a.scope = scopePackage
+	case a.scope == "":
+		a.scope = scopeRule
+	case a.scope == scopePackage || a.scope == scopeSubpackages:
+		if !isPackage {
+			return nil, fmt.Errorf("%v scope must annotate a package", a.scope)
+		}
+	case a.scope == scopeRule || a.scope == scopeDocument:
+		if isPackage {
+			return nil, fmt.Errorf("%v scope must annotate a rule", a.scope)
+		}
+	default:
+		return nil, fmt.Errorf("unknown scope: %v", a.scope)
+	}
+
+	return a, nil
+}
+
+// lookup returns the lint ignores of the most specific scope that contains
+// issue. Rule scopes are the most specific, followed by document, package,
+// and subpackages scopes. Ignores of less specific scopes do not apply to
+// issues in more specific scopes, e.g., a rule annotated with an empty list
+// of lint ignores reports all issues, even if its package ignores some rules.
+func (index *annotationIndex) lookup(issue Issue) []*annotationIgnore {
+
+	if issue.File == "" {
+		return nil
+	}
+
+	pkg, ok := index.pkgs[issue.File]
+	if !ok {
+		return nil
+	}
+
+	name := ""
+
+	for _, span := range index.spans[issue.File] {
+		if issue.Row >= span.start && issue.Row <= span.end {
+			name = span.name
+		}
+	}
+
+	var rule, document, pkgs, subpkgs []*annotationIgnore
+	longest := -1
+
+	for _, a := range index.ignores {
+		switch a.scope {
+		case scopeRule:
+			if a.file == issue.File && issue.Row >= a.start && issue.Row <= a.end {
+				rule = append(rule, a)
+			}
+		case scopeDocument:
+			if name != "" && a.pkg == pkg && a.name == name {
+				document = append(document, a)
+			}
+		case scopePackage:
+			if a.file == issue.File {
+				pkgs = append(pkgs, a)
+			}
+		case scopeSubpackages:
+			if a.pkg == pkg || strings.HasPrefix(pkg, a.pkg+".") {
+				if len(a.pkg) > longest {
+					subpkgs, longest = nil, len(a.pkg)
+				}
+				if len(a.pkg) == longest {
+					subpkgs = append(subpkgs, a)
+				}
+			}
+		}
+	}
+
+	for _, scope := range [][]*annotationIgnore{rule, document, pkgs, subpkgs} {
+		if len(scope) > 0 {
+			return scope
+		}
+	}
+
+	return nil
+}
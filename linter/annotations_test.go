@@ -0,0 +1,279 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestMetadataBlocks(t *testing.T) {
+
+	lines := strings.Split(`# METADATA
+# scope: package
+#   nested: true
+package a
+
+# regular comment
+#METADATA
+# custom: {}
+foo = true`, "\n")
+
+	expected := []metadataBlock{
+		{row: 1, target: 4, text: "scope: package\n  nested: true"},
+		{row: 7, target: 9, text: "custom: {}"},
+	}
+
+	if result := metadataBlocks(lines); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, result)
+	}
+}
+
+func TestRunnerLintAnnotationIgnores(t *testing.T) {
+
+	sources := map[string]string{
+		// The package ignores no-foo in this file only. Rule scopes take
+		// precedence over the package scope.
+		"a.rego": `# METADATA
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+package a
+
+foo = true
+
+# METADATA
+# custom:
+#   lint:
+#     ignore: []
+bar = true
+
+# METADATA
+# custom:
+#   lint:
+#     ignore: style/*
+baz = true
+
+qux = true
+`,
+		// The package scope of a.rego does not leak into this file. The
+		// document scope applies to qux in a.rego as well.
+		"b.rego": `package a
+
+foo = true
+
+# METADATA
+# scope: document
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+qux = true
+`,
+		// Subpackage scopes apply to all files of the package and its
+		// subpackages.
+		"c.rego": `# METADATA
+# scope: subpackages
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+package x
+`,
+		"d.rego": `package x.y
+
+foo = true
+`,
+		"e.rego": `# METADATA
+# scope: rule
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+package e
+
+# METADATA
+# custom:
+#   lint:
+#     ignore: ["no-todo"]
+foo = true
+`,
+	}
+
+	modules := map[string]*ast.Module{}
+	raw := map[string][]byte{}
+
+	for file, src := range sources {
+		modules[file] = mustParseModule(file, src)
+		raw[file] = []byte(src)
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": mustParseModule("lint.rego", `package system.lint
+
+deny[{"id": "no-foo", "message": rule.head.name, "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+}
+
+deny[{"id": "style/naming", "message": rule.head.name, "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "baz"
+}`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetLintModules(rules).SetSources(raw)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	var result []string
+
+	for _, issue := range report.Issues {
+		result = append(result, issue.File+" "+issue.Message+" "+issue.RuleID)
+	}
+
+	sort.Strings(result)
+
+	expected := []string{
+		"a.rego bar no-foo",
+		"a.rego baz no-foo",
+		"b.rego foo no-foo",
+		"e.rego foo no-foo",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, result)
+	}
+
+	if report.Summary.Suppressed != 5 {
+		t.Fatalf("Expected 5 suppressed issues but got: %+v", report.Summary)
+	}
+
+	diagnostics := []string{"e.rego:1: invalid METADATA: rule scope must annotate a rule"}
+
+	if !reflect.DeepEqual(report.Diagnostics, diagnostics) {
+		t.Fatalf("Expected diagnostics %v but got: %v", diagnostics, report.Diagnostics)
+	}
+
+	notes := []string{"e.rego:8: METADATA lint ignore no-todo (rule scope) did not suppress any issues"}
+
+	if !reflect.DeepEqual(report.Notes, notes) {
+		t.Fatalf("Expected notes %v but got: %v", notes, report.Notes)
+	}
+}
+
+func TestRunnerLintAnnotationIgnoresTargets(t *testing.T) {
+
+	sources := map[string]string{
+		"p/a.rego": `# METADATA
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+package p
+
+foo = true
+`,
+		"p/b.rego": `# METADATA
+# custom:
+#   lint:
+#     ignore: ["no-bar"]
+package p
+
+bar = true
+`,
+		"x/c.rego": `# METADATA
+# scope: subpackages
+# custom:
+#   lint:
+#     ignore: ["no-foo"]
+package x
+
+# METADATA
+# custom:
+#   lint:
+#     ignore: 1
+foo = true
+`,
+		"x/y/d.rego": `package x.y
+
+foo = true
+`,
+	}
+
+	modules := map[string]*ast.Module{}
+	raw := map[string][]byte{}
+
+	for file, src := range sources {
+		modules[file] = mustParseModule(file, src)
+		raw[file] = []byte(src)
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": mustParseModule("lint.rego", `package system.lint
+
+deny[{"id": "no-foo", "message": rule.head.name, "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+}`),
+	}
+
+	// The lint ignores of modules that are not provided to the lint rules
+	// still apply to the modules that are. Their diagnostics and notes are
+	// not reported.
+	tests := []struct {
+		note       string
+		filter     string
+		ignore     []string
+		sample     *Sample
+		suppressed int
+	}{
+		{note: "filter", filter: "^(p/a|x/y/)", suppressed: 2},
+		{note: "ignore", ignore: []string{"p/b.rego", "x/c.rego"}, suppressed: 2},
+		{note: "sample", ignore: []string{"p/*", "x/c.rego"}, sample: &Sample{Count: 1, Seed: "a"}, suppressed: 1},
+		{note: "all", filter: "^(p|x/y)/", ignore: []string{"p/b.rego"}, sample: &Sample{Fraction: 1, Seed: "a"}, suppressed: 2},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tests {
+
+		runner := New().
+			SetModules(modules).
+			SetLintModules(rules).
+			SetSources(raw).
+			Filter(tc.filter).
+			SetIgnore(tc.ignore).
+			SetSample(tc.sample)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		if len(report.Issues) != 0 {
+			t.Errorf("%v: Expected no issues but got: %v", tc.note, report.Issues)
+		}
+
+		if report.Summary.Suppressed != tc.suppressed {
+			t.Errorf("%v: Expected %d suppressed issues but got: %+v", tc.note, tc.suppressed, report.Summary)
+		}
+
+		if len(report.Diagnostics) != 0 || len(report.Notes) != 0 {
+			t.Errorf("%v: Expected no diagnostics or notes but got: %v %v", tc.note, report.Diagnostics, report.Notes)
+		}
+	}
+}
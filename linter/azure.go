@@ -0,0 +1,66 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// printAzure prints report as Azure Pipelines logging commands. Each issue is
+// logged as a build issue. If any issues reached the fail-on threshold (see
+// Summary.Failures), the task is marked as failed. If issues were found but
+// none reached the threshold, the task is marked as succeeded with issues.
+func (r *Runner) printAzure(report *Report) error {
+
+	w := bufio.NewWriter(r.output)
+
+	for _, issue := range sortedIssues(report.Issues) {
+
+		props := "type=" + azureType(issue.Level())
+
+		if issue.File != "" {
+			props += ";sourcepath=" + azurePropertyReplacer.Replace(issue.File)
+		}
+
+		if issue.Row > 0 {
+			props += fmt.Sprintf(";linenumber=%d", issue.Row)
+		}
+
+		if issue.Col > 0 {
+			props += fmt.Sprintf(";columnnumber=%d", issue.Col)
+		}
+
+		if issue.RuleID != "" {
+			props += ";code=" + azurePropertyReplacer.Replace(issue.RuleID)
+		}
+
+		fmt.Fprintf(w, "##vso[task.logissue %v]%v\n", props, azureMessageReplacer.Replace(issue.Message))
+	}
+
+	switch {
+	case report.Summary.Failures > 0:
+		fmt.Fprintln(w, "##vso[task.complete result=Failed]")
+	case len(report.Issues) > 0:
+		fmt.Fprintln(w, "##vso[task.complete result=SucceededWithIssues]")
+	}
+
+	return w.Flush()
+}
+
+// azureType returns the logissue type of an issue with level. Azure
+// Pipelines only distinguishes errors and warnings.
+func azureType(level string) string {
+	switch level {
+	case SeverityWarn, SeverityInfo:
+		return "warning"
+	}
+	return "error"
+}
+
+var azureMessageReplacer = strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", "]", "%5D")
+
+var azurePropertyReplacer = strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", "]", "%5D", ";", "%3B")
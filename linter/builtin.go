@@ -0,0 +1,101 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Built-in checks are implemented in Go and run over the linted modules in
+// addition to the lint rules. Issues produced by a built-in check have their
+// RuleID set to the check's identifier.
+const (
+
+	// BuiltinConflictingDefault reports default rules that shadow an import
+	// of the same name. References to the name inside the module refer to the
+	// rule instead of the imported document, so the default is almost always
+	// a mistake. Multiple default rules for the same document (in the same or
+	// different files) are rejected by the compiler.
+	BuiltinConflictingDefault = "builtin/conflicting-default"
+)
+
+type builtinCheck struct {
+	id          string
+	description string
+	check       func(modules map[string]*ast.Module) []Issue
+}
+
+var builtinChecks = []builtinCheck{
+	{BuiltinConflictingDefault, "default rules must not shadow imports", checkConflictingDefaults},
+}
+
+func isBuiltinCheck(id string) bool {
+	for _, builtin := range builtinChecks {
+		if builtin.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func checkConflictingDefaults(modules map[string]*ast.Module) []Issue {
+
+	var ids []string
+
+	for id := range modules {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	var issues []Issue
+
+	for _, id := range ids {
+		module := modules[id]
+		imports := map[ast.Var]*ast.Import{}
+		for _, imp := range module.Imports {
+			imports[imp.Name()] = imp
+		}
+		for _, rule := range module.Rules {
+			if !rule.Default {
+				continue
+			}
+			imp, ok := imports[rule.Head.Name]
+			if !ok {
+				continue
+			}
+			issue := newBuiltinIssue(BuiltinConflictingDefault, rule.Loc(), fmt.Sprintf("default rule %v shadows import %v", rule.Head.Name, imp.Path))
+			issue.Severity = SeverityWarn
+			if loc := imp.Loc(); loc != nil {
+				issue.Extra = map[string]interface{}{
+					"import": map[string]interface{}{
+						"file": loc.File,
+						"row":  loc.Row,
+						"col":  loc.Col,
+					},
+				}
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+func newBuiltinIssue(id string, loc *ast.Location, msg string) Issue {
+	issue := Issue{
+		Message: msg,
+		RuleID:  id,
+	}
+	if loc != nil {
+		issue.File = loc.File
+		issue.Row = loc.Row
+		issue.Col = loc.Col
+	}
+	return issue
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestBuiltinConflictingDefault(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		modules  map[string]string
+		disabled bool
+		issues   []string
+		err      string
+	}{
+		{
+			note: "default shadows import",
+			modules: map[string]string{
+				"a.rego": `package a
+
+import data.b.allow
+
+default allow = false`,
+				"b.rego": `package b
+
+allow = true`,
+			},
+			issues: []string{"a.rego:5: default rule allow shadows import data.b.allow"},
+		},
+		{
+			note: "default shadows aliased import",
+			modules: map[string]string{
+				"a.rego": `package a
+
+import data.b.allow as ok
+
+default ok = false`,
+			},
+			issues: []string{"a.rego:5: default rule ok shadows import data.b.allow"},
+		},
+		{
+			note: "single default",
+			modules: map[string]string{
+				"a.rego": `package a
+
+import data.b.allow
+
+default deny = false`,
+				"b.rego": `package a
+
+default allow = true`,
+			},
+		},
+		{
+			note:     "disabled",
+			disabled: true,
+			modules: map[string]string{
+				"a.rego": `package a
+
+import data.b.allow
+
+default allow = false`,
+			},
+		},
+		{
+			note: "same file",
+			modules: map[string]string{
+				"a.rego": `package a
+
+default allow = false
+default allow = true`,
+			},
+			err: "multiple default rules named allow found",
+		},
+		{
+			note: "cross file",
+			modules: map[string]string{
+				"a.rego": `package a
+
+default allow = false`,
+				"b.rego": `package a
+
+default allow = true`,
+			},
+			err: "multiple default rules named allow found",
+		},
+	}
+
+	for _, tc := range tests {
+
+		modules := map[string]*ast.Module{}
+
+		for id, src := range tc.modules {
+			modules[id] = mustParseModule(id, src)
+		}
+
+		ctx := context.Background()
+		runner := New().SetModules(modules)
+
+		if tc.disabled {
+			runner.DisableBuiltin(BuiltinConflictingDefault)
+		}
+
+		err := runner.Compile(ctx)
+
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%v: expected error %q but got: %v", tc.note, tc.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%v: unexpected compile error: %v", tc.note, err)
+			continue
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Errorf("%v: unexpected lint error: %v", tc.note, err)
+			continue
+		}
+
+		var issues []string
+
+		for _, issue := range report.Issues {
+			if issue.RuleID != BuiltinConflictingDefault {
+				t.Errorf("%v: unexpected rule id: %v", tc.note, issue.RuleID)
+			}
+			issues = append(issues, fmt.Sprintf("%v:%v: %v", issue.File, issue.Row, issue.Message))
+		}
+
+		if strings.Join(issues, "\n") != strings.Join(tc.issues, "\n") {
+			t.Errorf("%v: expected issues %v but got: %v", tc.note, tc.issues, issues)
+		}
+	}
+}
+
+func TestRunnerCompileUnknownBuiltin(t *testing.T) {
+	runner := New().DisableBuiltin("builtin/no-such-check")
+	if err := runner.Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for unknown built-in check")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr,omitempty"`
+}
+
+// printCheckstyle prints report as checkstyle XML. Files without issues are
+// omitted.
+func (r *Runner) printCheckstyle(report *Report) error {
+
+	result := checkstyleReport{Version: "4.3"}
+
+	for _, issue := range sortedIssues(report.Issues) {
+		name := issue.File
+		if name == "" {
+			name = "(no file)"
+		}
+		if n := len(result.Files); n == 0 || result.Files[n-1].Name != name {
+			result.Files = append(result.Files, checkstyleFile{Name: name})
+		}
+		file := &result.Files[len(result.Files)-1]
+		file.Errors = append(file.Errors, checkstyleError{
+			Line:     issue.Row,
+			Column:   issue.Col,
+			Severity: checkstyleSeverity(issue.Level()),
+			Message:  issue.Message,
+			Source:   issue.RuleID,
+		})
+	}
+
+	bs, err := xml.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.output, "%v%s\n", xml.Header, bs)
+	return err
+}
+
+// checkstyleSeverity returns the checkstyle severity of an issue with level.
+func checkstyleSeverity(level string) string {
+	switch level {
+	case SeverityWarn:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "error"
+}
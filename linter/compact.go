@@ -0,0 +1,62 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// printCompact prints each issue on a single "file:row:col: message [id]"
+// line understood by editors such as Vim (quickfix) and Emacs
+// (compilation-mode). Segments that are not known are omitted. Warnings are
+// prefixed with "warning:" and informational issues with "info:". No other
+// lines are printed.
+func (r *Runner) printCompact(report *Report) error {
+
+	w := bufio.NewWriter(r.output)
+
+	for _, issue := range sortedIssues(report.Issues) {
+		fmt.Fprintln(w, compactIssue(issue))
+	}
+
+	return w.Flush()
+}
+
+// compactIssue returns the compact representation of issue.
+func compactIssue(issue Issue) string {
+
+	var s string
+
+	if issue.File != "" {
+		s = issue.File
+		if issue.Row > 0 {
+			s += fmt.Sprintf(":%d", issue.Row)
+			if issue.Col > 0 {
+				s += fmt.Sprintf(":%d", issue.Col)
+			}
+		}
+		s += ": "
+	}
+
+	switch issue.Level() {
+	case SeverityWarn:
+		s += "warning: "
+	case SeverityInfo:
+		s += "info: "
+	}
+
+	s += compactMessageReplacer.Replace(issue.Message)
+
+	if issue.RuleID != "" {
+		s += " [" + issue.RuleID + "]"
+	}
+
+	return s
+}
+
+// compactMessageReplacer keeps multi-line messages on a single line.
+var compactMessageReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
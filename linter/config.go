@@ -0,0 +1,235 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// configToInput returns the representation of the OPA configuration file
+// provided to the lint rules. The result contains the parsed configuration
+// under "config" and an entry for every key and array element under "keys":
+//
+//	{
+//	  "path": ["services", "acme", "credentials"],
+//	  "key": "credentials",
+//	  "value": ...,          # only set for scalar values
+//	  "location": {"file": ..., "row": ..., "col": ...}
+//	}
+//
+// Locations are derived from the layout of block-style YAML. Values written
+// in flow style (e.g., "{a: 1}") are located at their parent key.
+func configToInput(file string, bs []byte) (map[string]interface{}, error) {
+
+	js, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	var doc interface{}
+
+	if err := util.UnmarshalJSON(js, &doc); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%v: configuration must be an object but got %T", file, doc)
+	}
+
+	index := newYAMLIndex(bs)
+	keys := []interface{}{}
+	root := &ast.Location{File: file, Row: 1, Col: 1}
+
+	index.walk(nil, doc, 0, len(index.tokens), root, func(path []interface{}, value interface{}, loc *ast.Location) {
+		entry := map[string]interface{}{
+			"path": path,
+			"key":  path[len(path)-1],
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			entry["value"] = value
+		}
+		setLocation(entry, loc)
+		keys = append(keys, entry)
+	})
+
+	return map[string]interface{}{
+		"config": doc,
+		"keys":   keys,
+	}, nil
+}
+
+// yamlToken is a key or sequence item in a YAML document. Sequence items that
+// contain a value on the same line (e.g., "- name: x") produce two tokens.
+type yamlToken struct {
+	row  int
+	col  int
+	dash bool
+	text string
+}
+
+// yamlIndex locates keys and sequence items in a block-style YAML document.
+type yamlIndex struct {
+	tokens []yamlToken
+}
+
+func newYAMLIndex(bs []byte) *yamlIndex {
+
+	index := &yamlIndex{}
+
+	for i, line := range strings.Split(string(bs), "\n") {
+
+		line = strings.TrimRight(line, "\r")
+		content := strings.TrimLeft(line, " ")
+		col := len(line) - len(content) + 1
+
+		if content == "---" || content == "..." {
+			continue
+		}
+
+		for content != "" && !strings.HasPrefix(content, "#") {
+			if content == "-" || strings.HasPrefix(content, "- ") {
+				index.tokens = append(index.tokens, yamlToken{row: i + 1, col: col, dash: true})
+				rest := strings.TrimLeft(content[1:], " ")
+				col += len(content) - len(rest)
+				content = rest
+				continue
+			}
+			index.tokens = append(index.tokens, yamlToken{row: i + 1, col: col, text: content})
+			break
+		}
+	}
+
+	return index
+}
+
+// walk calls f for every key and array element contained in x. The tokens in
+// the range [start, end) are the children of x in the document.
+func (index *yamlIndex) walk(path []interface{}, x interface{}, start, end int, loc *ast.Location, f func([]interface{}, interface{}, *ast.Location)) {
+
+	switch x := x.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			i := index.find(start, end, func(t yamlToken) bool {
+				key, ok := parseYAMLKey(t.text)
+				return !t.dash && ok && key == k
+			})
+			index.visit(path, k, x[k], i, loc, f)
+		}
+	case []interface{}:
+		n := 0
+		for j := range x {
+			i := index.find(start, end, func(t yamlToken) bool {
+				if !t.dash {
+					return false
+				}
+				n++
+				return n == j+1
+			})
+			n = 0
+			index.visit(path, json.Number(strconv.Itoa(j)), x[j], i, loc, f)
+		}
+	}
+}
+
+// visit calls f for the key or element k of a collection at path and then
+// walks its value. If k was not found in the document (i < 0), the location
+// of the collection is used.
+func (index *yamlIndex) visit(path []interface{}, k interface{}, value interface{}, i int, loc *ast.Location, f func([]interface{}, interface{}, *ast.Location)) {
+
+	child := make([]interface{}, len(path)+1)
+	copy(child, path)
+	child[len(path)] = k
+
+	start, end := 0, 0
+
+	if i >= 0 {
+		t := index.tokens[i]
+		loc = &ast.Location{File: loc.File, Row: t.row, Col: t.col}
+		start, end = i+1, index.end(i)
+	}
+
+	f(child, value, loc)
+	index.walk(child, value, start, end, loc, f)
+}
+
+// find returns the index of the first token in [start, end) at the indentation
+// of the block that satisfies pred. If no token satisfies pred, -1 is returned.
+func (index *yamlIndex) find(start, end int, pred func(yamlToken) bool) int {
+	if start >= end {
+		return -1
+	}
+	col := index.tokens[start].col
+	for i := start; i < end; i++ {
+		if t := index.tokens[i]; t.col == col && pred(t) {
+			return i
+		}
+	}
+	return -1
+}
+
+// end returns the index of the first token after token i that is not nested
+// under it. Sequence items may be indented at the same level as their key.
+func (index *yamlIndex) end(i int) int {
+	t := index.tokens[i]
+	j := i + 1
+	for j < len(index.tokens) {
+		next := index.tokens[j]
+		if next.col <= t.col && (t.dash || !next.dash || next.col < t.col) {
+			break
+		}
+		j++
+	}
+	return j
+}
+
+// parseYAMLKey returns the key of a "key: value" line.
+func parseYAMLKey(text string) (string, bool) {
+
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		q := strings.Index(text[1:], text[:1])
+		if q < 0 {
+			return "", false
+		}
+		key, rest := text[1:q+1], strings.TrimLeft(text[q+2:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", false
+		}
+		if text[0] == '"' {
+			if s, err := strconv.Unquote(text[:q+2]); err == nil {
+				key = s
+			}
+		}
+		return key, true
+	}
+
+	if i := strings.Index(text, ": "); i >= 0 {
+		return strings.TrimRight(text[:i], " "), true
+	}
+
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimRight(text[:len(text)-1], " "), true
+	}
+
+	return "", false
+}
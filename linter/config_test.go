@@ -0,0 +1,176 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// testConfigRules is an example lint rule that rejects plaintext credentials
+// in OPA configuration files.
+const testConfigRules = `package system.lint
+
+deny[{"message": msg, "location": k.location}] {
+	k = input.opa_configs[_].keys[_]
+	k.path[_] = "credentials"
+	k.key = "token"
+	not startswith(k.value, "${")
+	msg = "credentials must not be stored in plaintext"
+}`
+
+const testConfig = `# OPA configuration
+services:
+  - name: acme
+    url: https://example.com
+    credentials:
+      bearer:
+        token: "secret"
+  - name: other
+    url: https://example.org
+    credentials:
+      bearer:
+        token: "${TOKEN}"
+decision_logs:
+  service: acme
+  reporting:
+    min_delay_seconds: 300
+labels: {region: west}
+`
+
+func TestConfigToInputLocations(t *testing.T) {
+
+	input, err := configToInput("config.yaml", []byte(testConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	locations := map[string]string{}
+
+	for _, x := range input["keys"].([]interface{}) {
+		entry := x.(map[string]interface{})
+		loc := entry["location"].(map[string]interface{})
+		locations[fmt.Sprint(entry["path"])] = fmt.Sprintf("%v:%v", loc["row"], loc["col"])
+	}
+
+	expected := map[string]string{
+		"[services]":                                  "2:1",
+		"[services 0]":                                "3:3",
+		"[services 0 name]":                           "3:5",
+		"[services 0 url]":                            "4:5",
+		"[services 0 credentials]":                    "5:5",
+		"[services 0 credentials bearer]":             "6:7",
+		"[services 0 credentials bearer token]":       "7:9",
+		"[services 1]":                                "8:3",
+		"[services 1 name]":                           "8:5",
+		"[services 1 url]":                            "9:5",
+		"[services 1 credentials]":                    "10:5",
+		"[services 1 credentials bearer]":             "11:7",
+		"[services 1 credentials bearer token]":       "12:9",
+		"[decision_logs]":                             "13:1",
+		"[decision_logs service]":                     "14:3",
+		"[decision_logs reporting]":                   "15:3",
+		"[decision_logs reporting min_delay_seconds]": "16:5",
+		"[labels]":                                    "17:1",
+		"[labels region]":                             "17:1",
+	}
+
+	if !reflect.DeepEqual(locations, expected) {
+		t.Fatalf("Expected locations:\n%v\n\nGot:\n%v", expected, locations)
+	}
+}
+
+func TestConfigToInputUnindentedSequence(t *testing.T) {
+
+	input, err := configToInput("c.yaml", []byte("a:\n- x\n- y\nb: 1\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rows := map[string]interface{}{}
+
+	for _, x := range input["keys"].([]interface{}) {
+		entry := x.(map[string]interface{})
+		rows[fmt.Sprint(entry["path"])] = entry["location"].(map[string]interface{})["row"]
+	}
+
+	expected := map[string]interface{}{
+		"[a]":   json.Number("1"),
+		"[a 0]": json.Number("2"),
+		"[a 1]": json.Number("3"),
+		"[b]":   json.Number("4"),
+	}
+
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("Expected rows %v but got: %v", expected, rows)
+	}
+}
+
+func TestConfigToInputErrors(t *testing.T) {
+
+	tests := []struct {
+		note   string
+		config string
+	}{
+		{"bad yaml", "a: [1"},
+		{"not an object", "- a\n- b\n"},
+	}
+
+	for _, tc := range tests {
+		if _, err := configToInput("config.yaml", []byte(tc.config)); err == nil {
+			t.Errorf("%v: expected error", tc.note)
+		}
+	}
+}
+
+func TestRunnerLintConfigs(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testConfigRules),
+	}
+
+	configs := map[string][]byte{
+		"config.yaml":        []byte(testConfig),
+		"vendor/config.yaml": []byte(testConfig),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetConfigs(configs).SetIgnore([]string{"vendor"})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{
+		{
+			File:    "config.yaml",
+			Row:     7,
+			Col:     9,
+			Message: "credentials must not be stored in plaintext",
+			RuleID:  "system.lint.deny",
+		},
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues %v but got: %v", expected, report.Issues)
+	}
+}
+
+func TestRunnerCompileBadConfig(t *testing.T) {
+	runner := New().SetConfigs(map[string][]byte{"config.yaml": []byte("a: [1")})
+	if err := runner.Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for bad configuration file")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import "github.com/open-policy-agent/opa/ast"
+
+// DefaultRulesVersion is the version of the default lint rules. The version is
+// incremented whenever the default lint rules change.
+const DefaultRulesVersion = "1"
+
+// DefaultRulesFile is the file name of the default lint rules.
+const DefaultRulesFile = "default_lint_rules.rego"
+
+// DefaultRules contains the source of the default lint rules. The default lint
+// rules define violations for DefaultQuery and can be used as a starting point
+// for custom lint rules.
+const DefaultRules = `package system.lint
+
+# Default lint rules (version ` + DefaultRulesVersion + `).
+
+version = "` + DefaultRulesVersion + `"
+
+# Modules should define at least one rule.
+deny[{"id": "default/empty-module", "message": msg, "location": loc}] {
+	module = input.modules[_]
+	not module.rules
+	loc = module["package"].location
+	msg = "module does not define any rules"
+}
+
+# Rule names should be lowercase.
+deny[{"id": "default/rule-name-case", "message": msg, "location": loc}] {
+	rule = input.modules[_].rules[_]
+	re_match("[A-Z]", rule.head.name)
+	loc = rule.location
+	concat("", ["rule name should be lowercase: ", rule.head.name], msg)
+}
+
+# Documents should only be imported once.
+deny[{"id": "default/duplicate-import", "message": msg, "location": loc}] {
+	imports = input.modules[_].imports
+	imports[i].path = imports[j].path
+	i < j
+	loc = imports[j].location
+	msg = "duplicate import"
+}
+`
+
+// DefaultLintModules returns the parsed default lint rules keyed by
+// DefaultRulesFile.
+func DefaultLintModules() (map[string]*ast.Module, error) {
+
+	module, err := ast.ParseModule(DefaultRulesFile, DefaultRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]*ast.Module{
+		DefaultRulesFile: module,
+	}, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestDefaultRules(t *testing.T) {
+
+	rules, err := DefaultLintModules()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing default rules: %v", err)
+	}
+
+	modules := map[string]*ast.Module{
+		"empty.rego": mustParseModule("empty.rego", `package empty`),
+		"test.rego": mustParseModule("test.rego", `package test
+
+import data.a
+import data.a
+
+Allow = true
+allow = true`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetLintModules(rules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		return report.Issues[i].RuleID < report.Issues[j].RuleID
+	})
+
+	expected := []Issue{
+		{File: "test.rego", Row: 4, Col: 1, Message: "duplicate import", RuleID: "default/duplicate-import"},
+		{File: "empty.rego", Row: 1, Col: 1, Message: "module does not define any rules", RuleID: "default/empty-module"},
+		{File: "test.rego", Row: 6, Col: 1, Message: "rule name should be lowercase: Allow", RuleID: "default/rule-name-case"},
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues %v but got: %v", expected, report.Issues)
+	}
+}
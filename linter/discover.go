@@ -0,0 +1,111 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// DiscoveryError is returned by Compile if the lint query does not refer to
+// any rules but the lint modules define lint rules in other packages (see
+// LintPackages). Candidates contains the paths of these packages, e.g.,
+// "data.lint.style".
+type DiscoveryError struct {
+	Query      string
+	Candidates []string
+}
+
+func (err *DiscoveryError) Error() string {
+	return fmt.Sprintf("lint query %v is undefined: found lint rules in %v", err.Query, strings.Join(err.Candidates, ", "))
+}
+
+// discoverQuery returns the query evaluated by Lint. If the Runner's query
+// refers to rules defined in compiler or no lint module defines lint rules
+// (see LintPackages), the Runner's query is returned. The modules being linted
+// are not considered since policies commonly define "deny" rules themselves.
+// If auto-discovery is enabled and exactly one package defines such rules, the
+// "deny" rule of that package is returned. Otherwise, a DiscoveryError is
+// returned.
+func (r *Runner) discoverQuery(compiler *ast.Compiler) (string, error) {
+
+	for _, entry := range entrypoints(r.query) {
+		ref, err := ast.ParseRef(entry.query)
+		if err != nil || len(compiler.GetRulesWithPrefix(ref.GroundPrefix())) > 0 {
+			return r.query, nil
+		}
+	}
+
+	candidates := LintPackages(r.rules)
+
+	switch {
+	case len(candidates) == 0:
+		return r.query, nil
+	case len(candidates) == 1 && r.discover:
+		return candidates[0] + ".deny", nil
+	}
+
+	return "", &DiscoveryError{Query: r.query, Candidates: candidates}
+}
+
+// unusedLintRules returns a note for each package of the lint modules that
+// defines lint rules (see LintPackages) that none of queries refer to.
+func (r *Runner) unusedLintRules(queries []string) []string {
+
+	var notes []string
+
+	for _, path := range LintPackages(r.rules) {
+
+		pkg := ast.MustParseRef(path)
+		used := false
+
+		for _, query := range queries {
+			ref, err := ast.ParseRef(query)
+			if err != nil || pkg.HasPrefix(ref.GroundPrefix()) || ref.HasPrefix(pkg) {
+				used = true
+				break
+			}
+		}
+
+		if !used {
+			notes = append(notes, fmt.Sprintf("lint rules in %v are not evaluated by lint query %v", path, strings.Join(queries, ", ")))
+		}
+	}
+
+	return notes
+}
+
+// LintPackages returns the sorted paths of the packages in modules that define
+// "deny", "warn", or "info" partial set rules, i.e., the packages that
+// auto-discovery considers. Complete rules such as "default deny = false" are
+// common in policies and are not considered lint rules.
+func LintPackages(modules map[string]*ast.Module) []string {
+
+	paths := map[string]struct{}{}
+
+	for _, module := range modules {
+		for _, rule := range module.Rules {
+			if rule.Head.DocKind() != ast.PartialSetDoc {
+				continue
+			}
+			if name := string(rule.Head.Name); name == "deny" || name == SeverityWarn || name == SeverityInfo {
+				paths[module.Package.Path.String()] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(paths))
+
+	for path := range paths {
+		result = append(result, path)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
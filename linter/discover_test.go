@@ -0,0 +1,174 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestRunnerDiscoverQuery(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nfoo = true"),
+	}
+
+	style := `package lint.style
+
+deny[{"message": "rules must not be named foo"}] {
+	input.modules[_].rules[_].head.name = "foo"
+}`
+
+	naming := `package lint.naming
+
+warn[{"message": "rules should not be named foo"}] {
+	input.modules[_].rules[_].head.name = "foo"
+}`
+
+	tests := []struct {
+		note       string
+		rules      []string
+		discover   bool
+		query      string
+		candidates []string
+		issues     int
+	}{
+		{
+			note:     "auto-pick",
+			rules:    []string{style},
+			discover: true,
+			query:    "data.lint.style.deny",
+			issues:   1,
+		},
+		{
+			note:     "auto-pick warn",
+			rules:    []string{naming},
+			discover: true,
+			query:    "data.lint.naming.deny",
+			issues:   1,
+		},
+		{
+			note:       "disabled",
+			rules:      []string{style},
+			candidates: []string{"data.lint.style"},
+		},
+		{
+			note:       "ambiguous",
+			rules:      []string{style, naming},
+			discover:   true,
+			candidates: []string{"data.lint.naming", "data.lint.style"},
+		},
+		{
+			note:     "defined",
+			rules:    []string{style, testFormatRules},
+			discover: true,
+			query:    DefaultQuery,
+			issues:   2,
+		},
+		{
+			note:     "no candidates",
+			rules:    []string{"package lint.util\nname = n { input.modules[_].rules[_].head.name = n }"},
+			discover: true,
+			query:    DefaultQuery,
+		},
+	}
+
+	for _, tc := range tests {
+
+		rules := map[string]*ast.Module{}
+		for i, src := range tc.rules {
+			file := string(rune('a'+i)) + "_lint.rego"
+			rules[file] = mustParseModule(file, src)
+		}
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules).EnableAutoDiscover(tc.discover)
+		err := runner.Compile(ctx)
+
+		if tc.candidates != nil {
+			derr, ok := err.(*DiscoveryError)
+			if !ok {
+				t.Errorf("%v: expected discovery error but got: %v", tc.note, err)
+			} else if !reflect.DeepEqual(derr.Candidates, tc.candidates) || derr.Query != DefaultQuery {
+				t.Errorf("%v: expected candidates %v but got: %+v", tc.note, tc.candidates, derr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		if runner.Query() != tc.query {
+			t.Errorf("%v: expected query %v but got %v", tc.note, tc.query, runner.Query())
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		if len(report.Issues) != tc.issues {
+			t.Errorf("%v: expected %d issues but got: %+v", tc.note, tc.issues, report.Issues)
+		}
+	}
+}
+
+func TestRunnerDiscoverQueryPolicies(t *testing.T) {
+
+	// The deny rules of the policies being linted are not lint rules.
+	modules := map[string]*ast.Module{
+		"admission.rego": mustParseModule("admission.rego", `package kubernetes.admission
+
+deny[msg] {
+	input.request.kind.kind = "Pod"
+	msg = "pods are not allowed"
+}`),
+		"a.rego": mustParseModule("a.rego", "package a\ndefault deny = false\nfoo = true"),
+	}
+
+	style := `package lint.style
+
+deny[{"message": "rules must not be named foo"}] {
+	input.modules[_].rules[_].head.name = "foo"
+}`
+
+	ctx := context.Background()
+
+	rules := map[string]*ast.Module{
+		"style.rego": mustParseModule("style.rego", style),
+	}
+
+	runner := New().SetModules(modules).SetLintModules(rules).EnableAutoDiscover(true)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	if runner.Query() != "data.lint.style.deny" {
+		t.Fatalf("Expected query data.lint.style.deny but got %v", runner.Query())
+	}
+
+	rules["lint.rego"] = mustParseModule("lint.rego", testFormatRules)
+	runner = New().SetModules(modules).SetLintModules(rules).EnableAutoDiscover(true)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	notes := []string{"lint rules in data.lint.style are not evaluated by lint query data.system.lint.deny"}
+
+	if !reflect.DeepEqual(report.Notes, notes) {
+		t.Fatalf("Expected notes %v but got: %v", notes, report.Notes)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package linter checks policy modules against lint rules written in Rego.
+//
+// The modules being linted are provided to the lint rules as the input
+// document. Each module is keyed by its file name under input.modules and
+// contains the JSON representation of the module's AST. Packages, imports,
+// rules, and rule body expressions are annotated with a "location" object
+// that contains the file, row, and column where they were defined.
+//
+// Lint rules report violations by adding objects to the set produced by the
+// lint query (data.system.lint.deny by default). See Issue for the keys that
+// are recognized in the objects. For example:
+//
+//	package system.lint
+//
+//	deny[{"message": msg, "location": rule.location}] {
+//		rule = input.modules[_].rules[_]
+//		rule.head.name = "foo"
+//		msg = "rules must not be named foo"
+//	}
+package linter
@@ -0,0 +1,279 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Report formats supported by PrintReport.
+const (
+	FormatJSON       = "json"
+	FormatPretty     = "pretty"
+	FormatJUnit      = "junit"
+	FormatCheckstyle = "checkstyle"
+	FormatGitHub     = "github"
+	FormatGitLab     = "gitlab"
+	FormatTAP        = "tap"
+	FormatRDJSON     = "rdjson"
+	FormatTeamCity   = "teamcity"
+	FormatAzure      = "azure"
+	FormatHTML       = "html"
+	FormatMarkdown   = "markdown"
+	FormatTemplate   = "go-template"
+	FormatCompact    = "compact"
+)
+
+// Formats contains the report formats supported by PrintReport.
+var Formats = []string{
+	FormatJSON,
+	FormatPretty,
+	FormatJUnit,
+	FormatCheckstyle,
+	FormatGitHub,
+	FormatGitLab,
+	FormatTAP,
+	FormatRDJSON,
+	FormatTeamCity,
+	FormatAzure,
+	FormatHTML,
+	FormatMarkdown,
+	FormatTemplate,
+	FormatCompact,
+}
+
+// PrintReport prints report to the Runner's output in format. The JSON format
+// prints the Report object. The pretty format prints the issues grouped by
+// file along with the source line of each issue and a caret under the
+// issue's column. The source line is omitted if the source of the file is not
+// available (see SetSources) and the output is colorized if color is enabled
+// (see EnableColor). The JUnit format prints a test suite for each
+// linted file (see SetSuiteName). The checkstyle format prints a file element
+// for each file with issues. The GitHub format prints GitHub Actions workflow
+// commands that annotate the issues. The GitLab format prints a GitLab Code
+// Quality report. The TAP format prints a test point for each linted file.
+// The rdjson format prints reviewdog diagnostics. The TeamCity format prints
+// TeamCity inspection service messages. The Azure format prints Azure
+// Pipelines logging commands. The HTML format prints a self-contained HTML
+// document. The Markdown format prints a summary and a table of the issues
+// (see SetMarkdownOptions). The go-template format executes the Runner's
+// template against the TemplateData of the report (see SetTemplate). The
+// compact format prints each issue on a single "file:row:col: message" line.
+func (r *Runner) PrintReport(report *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.printJSON(report)
+	case FormatPretty:
+		return r.printPretty(report)
+	case FormatJUnit:
+		return r.printJUnit(report)
+	case FormatCheckstyle:
+		return r.printCheckstyle(report)
+	case FormatGitHub:
+		return r.printGitHub(report)
+	case FormatGitLab:
+		return r.printGitLab(report)
+	case FormatTAP:
+		return r.printTAP(report)
+	case FormatRDJSON:
+		return r.printRDJSON(report)
+	case FormatTeamCity:
+		return r.printTeamCity(report)
+	case FormatAzure:
+		return r.printAzure(report)
+	case FormatHTML:
+		return r.printHTML(report)
+	case FormatMarkdown:
+		return r.printMarkdown(report)
+	case FormatTemplate:
+		return r.printTemplate(report)
+	case FormatCompact:
+		return r.printCompact(report)
+	}
+	return fmt.Errorf("unknown report format: %v", format)
+}
+
+func (r *Runner) printJSON(report *Report) error {
+
+	bs, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(r.output, string(bs))
+	return err
+}
+
+func (r *Runner) printPretty(report *Report) error {
+
+	w := bufio.NewWriter(r.output)
+
+	issues := sortedIssues(report.Issues)
+
+	for i, issue := range issues {
+		if i == 0 || issue.File != issues[i-1].File {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			file := issue.File
+			if file == "" {
+				file = "(no file)"
+			}
+			fmt.Fprintf(w, "%v:\n", r.colorize(ansiDim, file))
+		}
+		color := severityColor(issue.Level())
+		fmt.Fprintf(w, "  %v\n", r.colorize(color, prettyIssue(issue)))
+		if line, ok := r.issueLine(issue); ok {
+			fmt.Fprintf(w, "    %v\n    %v%v\n", line, caretIndent(line, issue.Col), r.colorize(ansiBold+color, "^"))
+		}
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No issues found.")
+	}
+
+	return w.Flush()
+}
+
+// ANSI escape sequences used by the pretty format.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// colorize returns s wrapped in the escape sequence color if color is
+// enabled on the Runner (see EnableColor).
+func (r *Runner) colorize(color, s string) string {
+	if !r.color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// severityColor returns the escape sequence used for issues with level.
+func severityColor(level string) string {
+	switch level {
+	case SeverityWarn:
+		return ansiYellow
+	case SeverityInfo:
+		return ansiCyan
+	}
+	return ansiRed
+}
+
+// prettyIssue returns the position, message, and identifier of issue.
+func prettyIssue(issue Issue) string {
+
+	var s string
+
+	if issue.Row > 0 {
+		s = fmt.Sprintf("%d:%d: ", issue.Row, issue.Col)
+	}
+
+	if issue.Severity != "" {
+		s += issue.Severity + ": "
+	}
+
+	s += issue.Message
+
+	if issue.RuleID != "" {
+		s += " (" + issue.RuleID + ")"
+	}
+
+	return s
+}
+
+// issueLine returns the source line that issue refers to. If the source of
+// the file is not available, the line attached to the issue is used.
+func (r *Runner) issueLine(issue Issue) (string, bool) {
+	if issue.Row <= 0 {
+		return "", false
+	}
+	if src, ok := r.sources[issue.File]; ok {
+		return sourceLine(src, issue.Row)
+	}
+	return issue.Line, issue.Line != ""
+}
+
+// caretIndent returns the whitespace that positions a caret under column col
+// of line. Tabs in line are preserved so that the caret stays aligned.
+func caretIndent(line string, col int) string {
+
+	indent := []rune{}
+
+	for _, c := range line {
+		if len(indent) >= col-1 {
+			break
+		}
+		if c == '\t' {
+			indent = append(indent, '\t')
+		} else {
+			indent = append(indent, ' ')
+		}
+	}
+
+	return string(indent)
+}
+
+// lintedFiles returns the sorted names of the modules and configuration files
+// provided to the lint rules.
+func (r *Runner) lintedFiles() []string {
+
+	modules, _ := r.targets()
+	files := make([]string, 0, len(modules))
+
+	for file := range modules {
+		files = append(files, file)
+	}
+
+	for file := range r.targetConfigs() {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// sortedIssues returns a copy of issues sorted by file and position.
+func sortedIssues(issues []Issue) []Issue {
+
+	sorted := make([]Issue, len(issues))
+	copy(sorted, issues)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		return a.Col < b.Col
+	})
+
+	return sorted
+}
+
+// truncateIssues returns the n issues with the highest severity level sorted
+// by file and position. Issues with the same level are kept in order.
+func truncateIssues(issues []Issue, n int) []Issue {
+
+	kept := make([]Issue, len(issues))
+	copy(kept, issues)
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return severityRank(kept[i].Level()) > severityRank(kept[j].Level())
+	})
+
+	return sortedIssues(kept[:n])
+}
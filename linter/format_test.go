@@ -0,0 +1,1116 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+const testFormatRules = `package system.lint
+
+deny[{"id": "no-foo", "message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+deny[{"message": "policy set is too small", "severity": "warning"}] { true }`
+
+var testFormatSources = map[string][]byte{
+	"policies/a.rego": []byte(`package a
+
+foo = true`),
+	"policies/b.rego": []byte("package b\n\nbar = true\n\tfoo = true\nfoo = true"),
+	"policies/c.rego": []byte(`package c
+
+bar = true`),
+}
+
+func testFormatReport(t *testing.T, format string, sources bool) string {
+
+	modules := map[string]*ast.Module{}
+
+	for file, src := range testFormatSources {
+		modules[file] = mustParseModule(file, string(src))
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testFormatRules),
+	}
+
+	ctx := context.Background()
+	buf := bytes.NewBuffer(nil)
+	runner := New().SetModules(modules).SetLintModules(rules).SetOutput(buf)
+
+	if sources {
+		runner.SetSources(testFormatSources)
+	}
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	report.Summary.DurationMS = 0
+
+	if err := runner.PrintReport(report, format); err != nil {
+		t.Fatalf("Unexpected print error: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestPrintReportPretty(t *testing.T) {
+
+	expected := `(no file):
+  warn: policy set is too small (system.lint.deny)
+
+policies/a.rego:
+  3:1: rules must not be named foo (no-foo)
+
+policies/b.rego:
+  4:2: rules must not be named foo (no-foo)
+  5:1: rules must not be named foo (no-foo)
+`
+
+	if result := testFormatReport(t, FormatPretty, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportJSON(t *testing.T) {
+
+	expected := `{
+  "issues": [
+    {
+      "message": "policy set is too small",
+      "rule_id": "system.lint.deny",
+      "severity": "warn"
+    },
+    {
+      "file": "policies/a.rego",
+      "row": 3,
+      "col": 1,
+      "message": "rules must not be named foo",
+      "rule_id": "no-foo"
+    },
+    {
+      "file": "policies/b.rego",
+      "row": 4,
+      "col": 2,
+      "message": "rules must not be named foo",
+      "rule_id": "no-foo"
+    },
+    {
+      "file": "policies/b.rego",
+      "row": 5,
+      "col": 1,
+      "message": "rules must not be named foo",
+      "rule_id": "no-foo"
+    }
+  ],
+  "termination": {
+    "reason": "completed",
+    "unevaluated": 0
+  },
+  "summary": {
+    "files_scanned": 3,
+    "files_with_issues": 2,
+    "errors": 3,
+    "warnings": 1,
+    "infos": 0,
+    "failures": 3,
+    "disabled": 0,
+    "suppressed": 0,
+    "duration_ms": 0
+  },
+  "exit_reason": "3 issues with severity error or higher"
+}
+`
+
+	if result := testFormatReport(t, FormatJSON, true); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportPrettySnippets(t *testing.T) {
+
+	expected := "(no file):\n" +
+		"  warn: policy set is too small (system.lint.deny)\n" +
+		"\n" +
+		"policies/a.rego:\n" +
+		"  3:1: rules must not be named foo (no-foo)\n" +
+		"    foo = true\n" +
+		"    ^\n" +
+		"\n" +
+		"policies/b.rego:\n" +
+		"  4:2: rules must not be named foo (no-foo)\n" +
+		"    \tfoo = true\n" +
+		"    \t^\n" +
+		"  5:1: rules must not be named foo (no-foo)\n" +
+		"    foo = true\n" +
+		"    ^\n"
+
+	if result := testFormatReport(t, FormatPretty, true); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportPrettyColor(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a.rego", Row: 3, Col: 1, Message: "bad", RuleID: "no-foo", Line: "foo = true"},
+			{File: "a.rego", Row: 4, Col: 1, Message: "meh", Severity: "warn"},
+		},
+	}
+
+	expected := "\x1b[2ma.rego\x1b[0m:\n" +
+		"  \x1b[31m3:1: bad (no-foo)\x1b[0m\n" +
+		"    foo = true\n" +
+		"    \x1b[1m\x1b[31m^\x1b[0m\n" +
+		"  \x1b[33m4:1: warn: meh\x1b[0m\n"
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).EnableColor(true).PrintReport(report, FormatPretty); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != expected {
+		t.Fatalf("Expected %q but got %q", expected, buf.String())
+	}
+
+	for _, color := range []bool{true, false} {
+		for _, format := range Formats {
+			if format == FormatTemplate || (color && format == FormatPretty) {
+				continue
+			}
+			buf := bytes.NewBuffer(nil)
+			if err := New().SetOutput(buf).EnableColor(color).PrintReport(report, format); err != nil {
+				t.Fatalf("%v: Unexpected error: %v", format, err)
+			}
+			if strings.Contains(buf.String(), "\x1b[") {
+				t.Errorf("%v: Expected no escape sequences (color: %v) but got %q", format, color, buf.String())
+			}
+		}
+	}
+}
+
+func TestPrintReportJUnit(t *testing.T) {
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="policies" tests="5" failures="4">
+  <testsuite name="(no file)" package="policies" tests="1" failures="1">
+    <testcase name="system.lint.deny" classname="">
+      <failure message="policy set is too small" type="system.lint.deny">[system.lint.deny] policy set is too small</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="policies/a.rego" package="policies" tests="1" failures="1">
+    <testcase name="no-foo at line 3" classname="policies/a.rego">
+      <failure message="rules must not be named foo" type="no-foo">policies/a.rego:3: [no-foo] rules must not be named foo</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="policies/b.rego" package="policies" tests="2" failures="2">
+    <testcase name="no-foo at line 4" classname="policies/b.rego">
+      <failure message="rules must not be named foo" type="no-foo">policies/b.rego:4: [no-foo] rules must not be named foo</failure>
+    </testcase>
+    <testcase name="no-foo at line 5" classname="policies/b.rego">
+      <failure message="rules must not be named foo" type="no-foo">policies/b.rego:5: [no-foo] rules must not be named foo</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="policies/c.rego" package="policies" tests="1" failures="0">
+    <testcase name="lint" classname="policies/c.rego"></testcase>
+  </testsuite>
+</testsuites>
+`
+
+	if result := testFormatReport(t, FormatJUnit, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportJUnitEscaping(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a.rego", Row: 1, Col: 1, Message: `x < y && "z"`, RuleID: "cmp"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).SetSuiteName("my suite").PrintReport(report, FormatJUnit); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, s := range []string{
+		`<testsuites name="my suite"`,
+		`message="x &lt; y &amp;&amp; &#34;z&#34;"`,
+		`a.rego:1: [cmp] x &lt; y &amp;&amp; &#34;z&#34;</failure>`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected output to contain %q but got:\n%v", s, buf.String())
+		}
+	}
+}
+
+func TestPrintReportCheckstyle(t *testing.T) {
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="(no file)">
+    <error severity="warning" message="policy set is too small" source="system.lint.deny"></error>
+  </file>
+  <file name="policies/a.rego">
+    <error line="3" column="1" severity="error" message="rules must not be named foo" source="no-foo"></error>
+  </file>
+  <file name="policies/b.rego">
+    <error line="4" column="2" severity="error" message="rules must not be named foo" source="no-foo"></error>
+    <error line="5" column="1" severity="error" message="rules must not be named foo" source="no-foo"></error>
+  </file>
+</checkstyle>
+`
+
+	if result := testFormatReport(t, FormatCheckstyle, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportCheckstyleEscaping(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a&b.rego", Row: 1, Col: 1, Message: `x < y && "z"`, Severity: "info"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(report, FormatCheckstyle); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `<file name="a&amp;b.rego">
+    <error line="1" column="1" severity="info" message="x &lt; y &amp;&amp; &#34;z&#34;"></error>`
+
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("Expected output to contain %q but got:\n%v", expected, buf.String())
+	}
+}
+
+func TestPrintReportGitHub(t *testing.T) {
+
+	expected := `::warning::[system.lint.deny] policy set is too small
+::error file=policies/a.rego,line=3,col=1::[no-foo] rules must not be named foo
+::error file=policies/b.rego,line=4,col=2::[no-foo] rules must not be named foo
+::error file=policies/b.rego,line=5,col=1::[no-foo] rules must not be named foo
+`
+
+	if result := testFormatReport(t, FormatGitHub, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportGitHubEscaping(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a,b:c.rego", Row: 1, Message: "100% bad\r\nsee: docs, please", Severity: "info"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(report, FormatGitHub); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "::notice file=a%2Cb%3Ac.rego,line=1::100%25 bad%0D%0Asee: docs, please\n"
+
+	if buf.String() != expected {
+		t.Fatalf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestPrintReportGitLab(t *testing.T) {
+
+	var result []map[string]interface{}
+
+	if err := json.Unmarshal([]byte(testFormatReport(t, FormatGitLab, true)), &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The issue without a location is omitted.
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 issues but got: %v", result)
+	}
+
+	issue := result[0]
+	fingerprint := issue["fingerprint"]
+	delete(issue, "fingerprint")
+
+	expected := map[string]interface{}{
+		"description": "rules must not be named foo",
+		"check_name":  "no-foo",
+		"severity":    "major",
+		"location": map[string]interface{}{
+			"path":  "policies/a.rego",
+			"lines": map[string]interface{}{"begin": float64(3)},
+		},
+	}
+
+	if !reflect.DeepEqual(issue, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, issue)
+	}
+
+	seen := map[interface{}]bool{fingerprint: true}
+
+	for _, issue := range result[1:] {
+		if seen[issue["fingerprint"]] {
+			t.Fatalf("Expected unique fingerprints but got: %v", result)
+		}
+		seen[issue["fingerprint"]] = true
+	}
+}
+
+func TestPrintReportGitLabLocations(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{Message: "policy set is too small", Severity: SeverityWarn},
+			{File: "a.rego", Message: "file is too long", RuleID: "x"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(report, FormatGitLab); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result []gitlabIssue
+
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := gitlabLocation{Path: "a.rego", Lines: gitlabLines{Begin: 1}}
+
+	if len(result) != 1 || result[0].Location != expected {
+		t.Fatalf("Expected one issue at %v but got: %+v", expected, result)
+	}
+}
+
+func TestGitLabFingerprintStability(t *testing.T) {
+
+	fingerprints := func(src string, row int) []string {
+		buf := bytes.NewBuffer(nil)
+		runner := New().SetOutput(buf).SetSources(map[string][]byte{"a.rego": []byte(src)})
+		report := &Report{
+			Issues: []Issue{
+				{File: "a.rego", Row: row, Col: 1, Message: "bad", RuleID: "x"},
+				{File: "b.rego", Row: row, Col: 1, Message: "bad", RuleID: "x"},
+			},
+		}
+		if err := runner.PrintReport(report, FormatGitLab); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result []gitlabIssue
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return []string{result[0].Fingerprint, result[1].Fingerprint}
+	}
+
+	before := fingerprints("package a\n\nfoo  =  true\n", 3)
+	after := fingerprints("package a\n\nbar = true\n\nfoo = true\n", 5)
+	changed := fingerprints("package a\n\nfoo = false\n", 3)
+
+	if before[0] != after[0] {
+		t.Fatalf("Expected fingerprint to be stable when unrelated lines move")
+	}
+
+	if before[0] == changed[0] {
+		t.Fatalf("Expected fingerprint to change when the line content changes")
+	}
+
+	// Without a source, the message is used instead of the line content.
+	if before[1] != after[1] {
+		t.Fatalf("Expected fingerprint without source to ignore the row")
+	}
+}
+
+func TestPrintReportTAP(t *testing.T) {
+
+	expected := `TAP version 13
+1..4
+not ok 1 - (no file)
+  ---
+  violations:
+    - rule: "system.lint.deny"
+      message: "policy set is too small"
+  ...
+not ok 2 - policies/a.rego
+  ---
+  violations:
+    - rule: "no-foo"
+      line: 3
+      message: "rules must not be named foo"
+  ...
+not ok 3 - policies/b.rego
+  ---
+  violations:
+    - rule: "no-foo"
+      line: 4
+      message: "rules must not be named foo"
+    - rule: "no-foo"
+      line: 5
+      message: "rules must not be named foo"
+  ...
+ok 4 - policies/c.rego
+`
+
+	if result := testFormatReport(t, FormatTAP, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportTAPNoFiles(t *testing.T) {
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(&Report{}, FormatTAP); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "TAP version 13\n1..0 # SKIP no files linted\n"
+
+	if buf.String() != expected {
+		t.Fatalf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestPrintReportRDJSON(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a.rego", Row: 3, Col: 1, EndRow: 3, EndCol: 11, Message: "span", RuleID: "no-foo"},
+			{File: "a.rego", Row: 5, Message: "no column", Severity: "warning"},
+			{File: "b.rego", Message: "no position", Severity: "info"},
+		},
+	}
+
+	expected := `{
+  "source": {
+    "name": "opa-lint"
+  },
+  "diagnostics": [
+    {
+      "message": "span",
+      "location": {
+        "path": "a.rego",
+        "range": {
+          "start": {
+            "line": 3,
+            "column": 1
+          },
+          "end": {
+            "line": 3,
+            "column": 11
+          }
+        }
+      },
+      "severity": "ERROR",
+      "code": {
+        "value": "no-foo"
+      }
+    },
+    {
+      "message": "no column",
+      "location": {
+        "path": "a.rego",
+        "range": {
+          "start": {
+            "line": 5
+          }
+        }
+      },
+      "severity": "WARNING"
+    },
+    {
+      "message": "no position",
+      "location": {
+        "path": "b.rego"
+      },
+      "severity": "INFO"
+    }
+  ]
+}
+`
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(report, FormatRDJSON); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, buf.String())
+	}
+}
+
+func TestPrintReportTeamCity(t *testing.T) {
+
+	expected := `##teamcity[inspectionType id='builtin/conflicting-default' name='builtin/conflicting-default' description='default rules must not shadow imports' category='opa lint']
+##teamcity[inspectionType id='no-foo' name='no-foo' description='rules must not be named foo' category='opa lint']
+##teamcity[inspectionType id='system.lint.deny' name='system.lint.deny' description='policy set is too small' category='opa lint']
+##teamcity[inspection typeId='system.lint.deny' message='policy set is too small' file='' SEVERITY='WARNING']
+##teamcity[inspection typeId='no-foo' message='rules must not be named foo' file='policies/a.rego' line='3' SEVERITY='ERROR']
+##teamcity[inspection typeId='no-foo' message='rules must not be named foo' file='policies/b.rego' line='4' SEVERITY='ERROR']
+##teamcity[inspection typeId='no-foo' message='rules must not be named foo' file='policies/b.rego' line='5' SEVERITY='ERROR']
+`
+
+	if result := testFormatReport(t, FormatTeamCity, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportTeamCityTypes(t *testing.T) {
+
+	rules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny[{"id": "no-foo", "message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+deny[{"id": id, "message": msg}] {
+	name = input.modules[_].rules[_].head.name
+	name = "bar"
+	concat("", ["no-", name], id)
+	concat(" ", ["rules must not be named", name], msg)
+}
+
+warn[{"id": "style/naming", "message": msg}] {
+	input.modules[_].rules[_].head.name = "baz"
+	msg = "rules should not be named baz"
+}`),
+	}
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nbar = true"),
+	}
+
+	ctx := context.Background()
+	buf := bytes.NewBuffer(nil)
+	runner := New().SetModules(modules).SetLintModules(rules).SetOutput(buf).DisableBuiltin(BuiltinConflictingDefault)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	if err := runner.PrintReport(report, FormatTeamCity); err != nil {
+		t.Fatalf("Unexpected print error: %v", err)
+	}
+
+	// Rules with constant ids are declared even if they do not produce any
+	// issues. Computed ids are declared once they are reported.
+	expected := `##teamcity[inspectionType id='no-bar' name='no-bar' description='no-bar' category='opa lint']
+##teamcity[inspectionType id='no-foo' name='no-foo' description='rules must not be named foo' category='opa lint']
+##teamcity[inspectionType id='style/naming' name='style/naming' description='style/naming' category='opa lint']
+##teamcity[inspection typeId='no-bar' message='rules must not be named bar' file='' SEVERITY='ERROR']
+`
+
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, buf.String())
+	}
+}
+
+func TestTeamCityEscape(t *testing.T) {
+
+	result := teamcityEscape("it's [a|b]\r\nnext\u2028")
+	expected := "it|'s |[a||b|]|r|nnext|l"
+
+	if result != expected {
+		t.Fatalf("Expected %q but got %q", expected, result)
+	}
+}
+
+func TestPrintReportAzure(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		issues   []Issue
+		failOn   string
+		expected string
+	}{
+		{
+			note:     "no issues",
+			expected: "",
+		},
+		{
+			note: "error",
+			issues: []Issue{
+				{File: "a.rego", Row: 3, Col: 1, Message: "bad", RuleID: "no-foo"},
+			},
+			expected: "##vso[task.logissue type=error;sourcepath=a.rego;linenumber=3;columnnumber=1;code=no-foo]bad\n" +
+				"##vso[task.complete result=Failed]\n",
+		},
+		{
+			note: "warning",
+			issues: []Issue{
+				{File: "a.rego", Row: 3, Message: "meh", Severity: "warning"},
+			},
+			expected: "##vso[task.logissue type=warning;sourcepath=a.rego;linenumber=3]meh\n" +
+				"##vso[task.complete result=SucceededWithIssues]\n",
+		},
+		{
+			note: "warnings failing",
+			issues: []Issue{
+				{File: "a.rego", Row: 3, Message: "meh", Severity: SeverityWarn},
+				{File: "b.rego", Row: 1, Message: "fyi", Severity: SeverityInfo},
+			},
+			failOn: SeverityWarn,
+			expected: "##vso[task.logissue type=warning;sourcepath=a.rego;linenumber=3]meh\n" +
+				"##vso[task.logissue type=warning;sourcepath=b.rego;linenumber=1]fyi\n" +
+				"##vso[task.complete result=Failed]\n",
+		},
+		{
+			note: "sanitized",
+			issues: []Issue{
+				{File: "a;b].rego", Message: "100%]\n##vso[task.complete result=Succeeded]"},
+			},
+			expected: "##vso[task.logissue type=error;sourcepath=a%3Bb%5D.rego]100%AZP25%5D%0A##vso[task.complete result=Succeeded%5D\n" +
+				"##vso[task.complete result=Failed]\n",
+		},
+	}
+
+	for _, tc := range tests {
+
+		buf := bytes.NewBuffer(nil)
+		failOn := tc.failOn
+
+		if failOn == "" {
+			failOn = SeverityError
+		}
+
+		report := &Report{Issues: tc.issues, Summary: summarize(tc.issues, 1, failOn, 0)}
+
+		if err := New().SetOutput(buf).PrintReport(report, FormatAzure); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", tc.note, err)
+		}
+
+		if buf.String() != tc.expected {
+			t.Errorf("%v: Expected %q but got %q", tc.note, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestPrintReportCompact(t *testing.T) {
+
+	expected := `warning: policy set is too small [system.lint.deny]
+policies/a.rego:3:1: rules must not be named foo [no-foo]
+policies/b.rego:4:2: rules must not be named foo [no-foo]
+policies/b.rego:5:1: rules must not be named foo [no-foo]
+`
+
+	if result := testFormatReport(t, FormatCompact, true); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestCompactIssue(t *testing.T) {
+
+	tests := []struct {
+		issue    Issue
+		expected string
+	}{
+		{Issue{File: "a.rego", Row: 3, Col: 5, Message: "bad", RuleID: "no-foo"}, "a.rego:3:5: bad [no-foo]"},
+		{Issue{File: "a.rego", Row: 3, Message: "bad"}, "a.rego:3: bad"},
+		{Issue{File: "a.rego", Message: "bad"}, "a.rego: bad"},
+		{Issue{File: "a.rego", Row: 3, Col: 5, Message: "meh", Severity: "warn"}, "a.rego:3:5: warning: meh"},
+		{Issue{File: "a.rego", Row: 3, Col: 5, Message: "meh", Severity: "warning", RuleID: "x"}, "a.rego:3:5: warning: meh [x]"},
+		{Issue{File: "a.rego", Row: 3, Col: 5, Message: "bad", Severity: "error"}, "a.rego:3:5: bad"},
+		{Issue{File: "a.rego", Row: 3, Col: 5, Message: "fyi", Severity: "info"}, "a.rego:3:5: info: fyi"},
+		{Issue{File: "a.rego", Row: 1, Col: 1, Message: "two\nlines"}, "a.rego:1:1: two lines"},
+	}
+
+	for _, tc := range tests {
+		if result := compactIssue(tc.issue); result != tc.expected {
+			t.Errorf("Expected %q but got %q", tc.expected, result)
+		}
+	}
+}
+
+func TestPrintReportHTML(t *testing.T) {
+
+	expected := `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>OPA lint report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+pre { background: #f6f6f6; padding: 0.5em; }
+.severity { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>OPA lint report</h1>
+<p>4 issue(s) found.</p>
+<h2>Issues by rule</h2>
+<table>
+<tr><th>Rule</th><th>Issues</th></tr>
+<tr><td>no-foo</td><td>3</td></tr>
+<tr><td>system.lint.deny</td><td>1</td></tr>
+</table>
+<h2>Issues by file</h2>
+<table>
+<tr><th>File</th><th>Issues</th></tr>
+<tr><td>policies/b.rego</td><td>2</td></tr>
+<tr><td>(no file)</td><td>1</td></tr>
+<tr><td>policies/a.rego</td><td>1</td></tr>
+</table>
+<h2>(no file)</h2>
+<ul>
+<li><span class="severity">warn</span> policy set is too small <code>system.lint.deny</code>
+</li>
+</ul>
+<h2>policies/a.rego</h2>
+<ul>
+<li><span class="position">3:1</span> rules must not be named foo <code>no-foo</code>
+<pre>foo = true
+^</pre>
+</li>
+</ul>
+<h2>policies/b.rego</h2>
+<ul>
+<li><span class="position">4:2</span> rules must not be named foo <code>no-foo</code>
+<pre>	foo = true
+	^</pre>
+</li>
+<li><span class="position">5:1</span> rules must not be named foo <code>no-foo</code>
+<pre>foo = true
+^</pre>
+</li>
+</ul>
+</body>
+</html>
+`
+
+	if result := testFormatReport(t, FormatHTML, true); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportHTMLEscaping(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "<a>.rego", Row: 1, Col: 1, Message: "<script>alert(1)</script>", RuleID: "x&y"},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	runner := New().SetOutput(buf).SetSources(map[string][]byte{
+		"<a>.rego": []byte("p = \"<b>\""),
+	})
+
+	if err := runner.PrintReport(report, FormatHTML); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, s := range []string{"<script>", "<a>", "<b>"} {
+		if strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected %q to be escaped but got:\n%v", s, buf.String())
+		}
+	}
+
+	for _, s := range []string{"&lt;script&gt;alert(1)&lt;/script&gt;", "<h2>&lt;a&gt;.rego</h2>", "<code>x&amp;y</code>", "p = &#34;&lt;b&gt;&#34;"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected output to contain %q but got:\n%v", s, buf.String())
+		}
+	}
+}
+
+func TestPrintReportMarkdown(t *testing.T) {
+
+	expected := "**4 violations across 3 files**\n" +
+		"\n" +
+		"| File | Line | Rule | Message |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `(no file)` |  | `system.lint.deny` | policy set is too small |\n" +
+		"| `policies/a.rego` | 3 | `no-foo` | rules must not be named foo |\n" +
+		"| `policies/b.rego` | 4 | `no-foo` | rules must not be named foo |\n" +
+		"| `policies/b.rego` | 5 | `no-foo` | rules must not be named foo |\n"
+
+	if result := testFormatReport(t, FormatMarkdown, false); result != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, result)
+	}
+}
+
+func TestPrintReportMarkdownDetails(t *testing.T) {
+
+	report := &Report{
+		Issues: []Issue{
+			{File: "a.rego", Row: 1, Message: "x | y", RuleID: "r|1"},
+			{File: "b.rego", Row: 2, Message: "multi\nline"},
+			{File: "b.rego", Row: 3, Message: "z"},
+		},
+	}
+
+	expected := "**3 violations across 2 files**\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary><code>a.rego</code> (1 violation)</summary>\n" +
+		"\n" +
+		"| Line | Rule | Message |\n" +
+		"| --- | --- | --- |\n" +
+		"| 1 | `r\\|1` | x \\| y |\n" +
+		"\n" +
+		"</details>\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary><code>b.rego</code> (2 violations)</summary>\n" +
+		"\n" +
+		"| Line | Rule | Message |\n" +
+		"| --- | --- | --- |\n" +
+		"| 2 |  | multi<br>line |\n" +
+		"| 3 |  | z |\n" +
+		"\n" +
+		"</details>\n"
+
+	buf := bytes.NewBuffer(nil)
+	runner := New().SetOutput(buf).SetMarkdownOptions(MarkdownOptions{DetailsThreshold: 2})
+
+	if err := runner.PrintReport(report, FormatMarkdown); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expected, buf.String())
+	}
+}
+
+func TestPrintReportMarkdownTruncated(t *testing.T) {
+
+	report := &Report{}
+
+	for i := 0; i < 100; i++ {
+		report.Issues = append(report.Issues, Issue{File: "a.rego", Row: i + 1, Message: strings.Repeat("x", 50)})
+	}
+
+	for _, threshold := range []int{200, 10} {
+
+		buf := bytes.NewBuffer(nil)
+		runner := New().SetOutput(buf).SetMarkdownOptions(MarkdownOptions{DetailsThreshold: threshold, MaxSize: 1000})
+
+		if err := runner.PrintReport(report, FormatMarkdown); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		result := buf.String()
+
+		if len(result) > 1000 {
+			t.Fatalf("Expected output to be at most 1000 bytes but got %d", len(result))
+		}
+
+		if !strings.Contains(result, "of 100 violations shown._") {
+			t.Fatalf("Expected truncation note but got:\n%v", result)
+		}
+
+		if threshold == 10 && !strings.Contains(result, "</details>\n\n_Output truncated: ") {
+			t.Fatalf("Expected details section to be closed before note but got:\n%v", result)
+		}
+	}
+}
+
+func TestPrintReportTemplate(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		template string
+		expected string
+	}{
+		{
+			note:     "per line",
+			template: `{{range .Issues}}{{.File}}:{{.Row}}: {{upper .Message}}{{with .RuleID}} [{{.}}]{{end}}` + "\n" + `{{end}}`,
+			expected: ":0: POLICY SET IS TOO SMALL [system.lint.deny]\n" +
+				"policies/a.rego:3: RULES MUST NOT BE NAMED FOO [no-foo]\n" +
+				"policies/b.rego:4: RULES MUST NOT BE NAMED FOO [no-foo]\n" +
+				"policies/b.rego:5: RULES MUST NOT BE NAMED FOO [no-foo]\n",
+		},
+		{
+			note: "grouped",
+			template: `{{.Summary.Issues}} issues in {{.Summary.Files}} of {{.Summary.Linted}} files ({{index .Summary.BySeverity "warn"}} warnings)
+{{range .Files}}{{if .Name}}{{.Name}}{{else}}-{{end}}: {{len .Issues}} {{json (index .Issues 0).Message}}
+{{end}}{{.Termination.Reason}}
+`,
+			expected: `4 issues in 3 of 3 files (1 warnings)
+-: 1 "policy set is too small"
+policies/a.rego: 1 "rules must not be named foo"
+policies/b.rego: 2 "rules must not be named foo"
+completed
+`,
+		},
+	}
+
+	for _, tc := range tests {
+
+		modules := map[string]*ast.Module{}
+
+		for file, src := range testFormatSources {
+			modules[file] = mustParseModule(file, string(src))
+		}
+
+		rules := map[string]*ast.Module{
+			"lint.rego": ast.MustParseModule(testFormatRules),
+		}
+
+		ctx := context.Background()
+		buf := bytes.NewBuffer(nil)
+		runner := New().SetModules(modules).SetLintModules(rules).SetOutput(buf).SetTemplate(tc.template)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		if err := runner.PrintReport(report, FormatTemplate); err != nil {
+			t.Fatalf("%v: Unexpected print error: %v", tc.note, err)
+		}
+
+		if buf.String() != tc.expected {
+			t.Errorf("%v: Expected:\n%v\n\nGot:\n%v", tc.note, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestPrintReportTemplateErrors(t *testing.T) {
+
+	err := New().SetTemplate("{{range .Issues}}").Compile(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Fatalf("Expected invalid template error but got: %v", err)
+	}
+
+	runner := New().SetOutput(bytes.NewBuffer(nil)).SetTemplate("ok\n{{.NoSuchField}}")
+
+	if err := runner.Compile(context.Background()); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	err = runner.PrintReport(&Report{}, FormatTemplate)
+	if err == nil || !strings.Contains(err.Error(), "report:2:") {
+		t.Fatalf("Expected execution error with template line but got: %v", err)
+	}
+
+	err = New().PrintReport(&Report{}, FormatTemplate)
+	if err == nil {
+		t.Fatal("Expected error for missing template")
+	}
+}
+
+func TestTemplateRelpath(t *testing.T) {
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relpath := templateFuncs["relpath"].(func(string) string)
+
+	if result := relpath(filepath.Join(wd, "policies", "a.rego")); result != filepath.Join("policies", "a.rego") {
+		t.Fatalf("Expected path relative to working directory but got: %v", result)
+	}
+
+	if result := relpath("policies/a.rego"); result != "policies/a.rego" {
+		t.Fatalf("Expected relative path to be unchanged but got: %v", result)
+	}
+}
+
+func TestJUnitSuiteName(t *testing.T) {
+
+	tests := []struct {
+		files    []string
+		expected string
+	}{
+		{nil, "opa lint"},
+		{[]string{"a.rego"}, "opa lint"},
+		{[]string{"policies/a.rego", "policies/authz/b.rego"}, "policies"},
+		{[]string{"/tmp/repo/policies/a.rego", "/tmp/repo/policies/b.rego"}, "policies"},
+		{[]string{"/tmp/repo/policies/a.rego", "/tmp/repo/lint/b.rego"}, "repo"},
+		{[]string{"policies/a.rego", "lint/b.rego"}, "opa lint"},
+	}
+
+	for _, tc := range tests {
+		if result := junitSuiteName(tc.files); result != tc.expected {
+			t.Errorf("Expected suite name for %v to be %q but got %q", tc.files, tc.expected, result)
+		}
+	}
+}
+
+func TestCaretIndent(t *testing.T) {
+
+	tests := []struct {
+		line     string
+		col      int
+		expected string
+	}{
+		{"foo = true", 0, ""},
+		{"foo = true", 1, ""},
+		{"foo = true", 5, "    "},
+		{"\t\tfoo", 3, "\t\t"},
+		{"\tx = \"é\"; y", 9, "\t       "},
+		{"ab", 10, "  "},
+	}
+
+	for _, tc := range tests {
+		if result := caretIndent(tc.line, tc.col); result != tc.expected {
+			t.Errorf("Expected caret indent for %q at %d to be %q but got %q", tc.line, tc.col, tc.expected, result)
+		}
+	}
+}
+
+func TestPrintReportEmpty(t *testing.T) {
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintReport(&Report{}, FormatPretty); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if buf.String() != "No issues found.\n" {
+		t.Fatalf("Expected no issues message but got: %q", buf.String())
+	}
+
+	if err := New().PrintReport(&Report{}, "xml"); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// printGitHub prints report as GitHub Actions workflow commands so that
+// issues are shown as annotations. The summary is printed separately (see
+// PrintSummary).
+func (r *Runner) printGitHub(report *Report) error {
+
+	w := bufio.NewWriter(r.output)
+
+	for _, issue := range sortedIssues(report.Issues) {
+
+		var props []string
+
+		if issue.File != "" {
+			props = append(props, "file="+githubEscapeProperty(issue.File))
+		}
+
+		if issue.Row > 0 {
+			props = append(props, fmt.Sprintf("line=%d", issue.Row))
+		}
+
+		if issue.Col > 0 {
+			props = append(props, fmt.Sprintf("col=%d", issue.Col))
+		}
+
+		msg := issue.Message
+		if issue.RuleID != "" {
+			msg = "[" + issue.RuleID + "] " + msg
+		}
+
+		cmd := githubCommand(issue.Level())
+		if len(props) > 0 {
+			cmd += " " + strings.Join(props, ",")
+		}
+
+		fmt.Fprintf(w, "::%v::%v\n", cmd, githubEscapeData(msg))
+	}
+
+	return w.Flush()
+}
+
+// githubCommand returns the workflow command for an issue with level.
+func githubCommand(level string) string {
+	switch level {
+	case SeverityWarn:
+		return "warning"
+	case SeverityInfo:
+		return "notice"
+	}
+	return "error"
+}
+
+var githubDataReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+var githubPropertyReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// githubEscapeData escapes s for use as the message of a workflow command.
+func githubEscapeData(s string) string {
+	return githubDataReplacer.Replace(s)
+}
+
+// githubEscapeProperty escapes s for use as a property value of a workflow
+// command.
+func githubEscapeProperty(s string) string {
+	return githubPropertyReplacer.Replace(s)
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Severity    string         `json:"severity"`
+	Fingerprint string         `json:"fingerprint"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+// printGitLab prints report in the GitLab Code Quality (Code Climate) format.
+// Severities are mapped as follows: "info" to info, "warn" and "warning" to
+// minor, and all other severities to major.
+//
+// GitLab rejects Code Quality entries without a path or with line 0, so issues
+// without a file are omitted and issues without a row are reported at line 1.
+//
+// Fingerprints are derived from the rule id, the file, and the normalized
+// content of the issue's source line (or the message if the source line is
+// not available) so that they do not change when unrelated lines move.
+func (r *Runner) printGitLab(report *Report) error {
+
+	result := []gitlabIssue{}
+	seen := map[string]int{}
+
+	for _, issue := range sortedIssues(report.Issues) {
+
+		if issue.File == "" {
+			continue
+		}
+
+		check := issue.RuleID
+		if check == "" {
+			check = "opa-lint"
+		}
+
+		key := r.gitlabFingerprintKey(issue)
+		n := seen[key]
+		seen[key]++
+
+		// Issues with the same key are distinguished by the order they
+		// appear in.
+		if n > 0 {
+			key += "\x00" + strconv.Itoa(n)
+		}
+
+		sum := sha256.Sum256([]byte(key))
+
+		row := issue.Row
+		if row <= 0 {
+			row = 1
+		}
+
+		result = append(result, gitlabIssue{
+			Description: issue.Message,
+			CheckName:   check,
+			Severity:    gitlabSeverity(issue.Level()),
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Location: gitlabLocation{
+				Path:  issue.File,
+				Lines: gitlabLines{Begin: row},
+			},
+		})
+	}
+
+	bs, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(r.output, string(bs))
+	return err
+}
+
+func (r *Runner) gitlabFingerprintKey(issue Issue) string {
+
+	content := issue.Message
+
+	if line, ok := r.issueLine(issue); ok {
+		content = strings.Join(strings.Fields(line), " ")
+	}
+
+	return strings.Join([]string{issue.RuleID, issue.File, content}, "\x00")
+}
+
+// gitlabSeverity returns the Code Quality severity of an issue with level.
+func gitlabSeverity(level string) string {
+	switch level {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "minor"
+	}
+	return "major"
+}
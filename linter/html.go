@@ -0,0 +1,147 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"html/template"
+	"sort"
+)
+
+type htmlCount struct {
+	Name  string
+	Count int
+}
+
+type htmlIssue struct {
+	Issue
+	Snippet string
+}
+
+type htmlFile struct {
+	Name   string
+	Issues []htmlIssue
+}
+
+type htmlReport struct {
+	Total  int
+	Rules  []htmlCount
+	Files  []htmlCount
+	Issues []htmlFile
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>OPA lint report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+pre { background: #f6f6f6; padding: 0.5em; }
+.severity { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>OPA lint report</h1>
+<p>{{.Total}} issue(s) found.</p>
+{{- if .Rules}}
+<h2>Issues by rule</h2>
+<table>
+<tr><th>Rule</th><th>Issues</th></tr>
+{{- range .Rules}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+<h2>Issues by file</h2>
+<table>
+<tr><th>File</th><th>Issues</th></tr>
+{{- range .Files}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Issues}}
+<h2>{{.Name}}</h2>
+<ul>
+{{- range .Issues}}
+<li>
+{{- if .Row}}<span class="position">{{.Row}}{{if .Col}}:{{.Col}}{{end}}</span> {{end}}
+{{- if .Severity}}<span class="severity">{{.Severity}}</span> {{end}}
+{{- .Message}}
+{{- if .RuleID}} <code>{{.RuleID}}</code>{{end}}
+{{- if .Snippet}}
+<pre>{{.Snippet}}</pre>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// printHTML prints report as a self-contained HTML document that summarizes
+// the issues by rule and by file and lists the issues of each file along with
+// their source lines.
+func (r *Runner) printHTML(report *Report) error {
+
+	result := htmlReport{Total: len(report.Issues)}
+	rules := map[string]int{}
+	files := map[string]int{}
+
+	for _, issue := range sortedIssues(report.Issues) {
+
+		name := issue.File
+		if name == "" {
+			name = "(no file)"
+		}
+
+		rule := issue.RuleID
+		if rule == "" {
+			rule = "(no rule id)"
+		}
+
+		rules[rule]++
+		files[name]++
+
+		if n := len(result.Issues); n == 0 || result.Issues[n-1].Name != name {
+			result.Issues = append(result.Issues, htmlFile{Name: name})
+		}
+
+		x := htmlIssue{Issue: issue}
+
+		if line, ok := r.issueLine(issue); ok {
+			x.Snippet = line + "\n" + caretIndent(line, issue.Col) + "^"
+		}
+
+		file := &result.Issues[len(result.Issues)-1]
+		file.Issues = append(file.Issues, x)
+	}
+
+	result.Rules = htmlCounts(rules)
+	result.Files = htmlCounts(files)
+
+	return htmlTemplate.Execute(r.output, result)
+}
+
+// htmlCounts returns the counts sorted by decreasing count and name.
+func htmlCounts(counts map[string]int) []htmlCount {
+
+	result := make([]htmlCount, 0, len(counts))
+
+	for name, count := range counts {
+		result = append(result, htmlCount{Name: name, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ignorePatterns is a set of glob patterns matched against module file names.
+// Patterns use the syntax of filepath.Match. A pattern without a path separator
+// matches any element of the file name (e.g., "*_test.rego" or "vendor"). A
+// pattern with a path separator matches the file name or any of its parent
+// directories (e.g., "gen/*" matches "gen/a/b.rego").
+type ignorePatterns []string
+
+func (ps ignorePatterns) validate() error {
+	for _, p := range ps {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("bad ignore pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+func (ps ignorePatterns) Match(path string) bool {
+	for _, p := range ps {
+		if matchIgnorePattern(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchIgnorePattern(pattern, path string) bool {
+
+	path = filepath.ToSlash(path)
+	pattern = filepath.ToSlash(pattern)
+	parts := strings.Split(path, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, part := range parts {
+			if ok, _ := filepath.Match(pattern, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := len(parts); i > 0; i-- {
+		prefix := strings.Join(parts[:i], "/")
+		if ok, _ := filepath.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestIgnorePatternsMatch(t *testing.T) {
+
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*_test.rego", "a_test.rego", true},
+		{"*_test.rego", "policies/authz/a_test.rego", true},
+		{"*_test.rego", "policies/authz/a.rego", false},
+		{"vendor", "policies/vendor/lib/a.rego", true},
+		{"vendor", "policies/vendored/a.rego", false},
+		{"gen/*", "gen/a.rego", true},
+		{"gen/*", "gen/sub/a.rego", true},
+		{"gen/*", "src/gen/a.rego", false},
+		{"*/gen", "src/gen/a.rego", true},
+		{"policies/a.rego", "policies/a.rego", true},
+		{"policies/a.rego", "policies/b.rego", false},
+		{"nothing", "policies/a.rego", false},
+	}
+
+	for _, tc := range tests {
+		if result := ignorePatterns([]string{tc.pattern}).Match(tc.path); result != tc.match {
+			t.Errorf("Expected %q match %q to be %v", tc.pattern, tc.path, tc.match)
+		}
+	}
+}
+
+func TestRunnerLintIgnore(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint/rules.rego": ast.MustParseModule(`package system.lint
+
+deny[{"message": "module", "location": {"file": file}}] {
+	input.modules[file]
+}
+
+deny[{"message": "ignored file", "location": {"file": "gen/report.rego"}}] { true }`),
+		"policies/a.rego":      ast.MustParseModule(`package a`),
+		"policies/a_test.rego": ast.MustParseModule(`package a`),
+		"gen/b.rego":           ast.MustParseModule(`package b`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetIgnore([]string{"*_test.rego", "gen/*", "lint", "no-match"})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	var files []string
+
+	for _, issue := range report.Issues {
+		files = append(files, issue.File)
+	}
+
+	sort.Strings(files)
+
+	expected := []string{"policies/a.rego"}
+
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Expected issues for %v but got: %v", expected, report.Issues)
+	}
+}
+
+func TestRunnerCompileBadIgnore(t *testing.T) {
+	runner := New().SetIgnore([]string{"[a-"})
+	if err := runner.Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for bad ignore pattern")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// buildInput returns the input document provided to the lint rules. The OPA
+// configuration files are only included if configs is non-nil.
+func buildInput(modules map[string]*ast.Module, configs map[string]interface{}) (map[string]interface{}, error) {
+
+	result := map[string]interface{}{}
+
+	for id, module := range modules {
+		x, err := moduleToInput(module)
+		if err != nil {
+			return nil, err
+		}
+		result[id] = x
+	}
+
+	input := map[string]interface{}{
+		"modules": result,
+	}
+
+	if configs != nil {
+		input["opa_configs"] = configs
+	}
+
+	return input, nil
+}
+
+// moduleToInput returns the JSON representation of module annotated with the
+// locations of the module's statements. Locations are omitted from the
+// standard JSON representation of the AST.
+func moduleToInput(module *ast.Module) (map[string]interface{}, error) {
+
+	bs, err := json.Marshal(module)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+
+	if err := util.UnmarshalJSON(bs, &result); err != nil {
+		return nil, err
+	}
+
+	if pkg, ok := result["package"].(map[string]interface{}); ok {
+		setLocation(pkg, module.Package.Location)
+	}
+
+	if imports, ok := result["imports"].([]interface{}); ok {
+		for i := range imports {
+			if imp, ok := imports[i].(map[string]interface{}); ok {
+				setLocation(imp, module.Imports[i].Location)
+			}
+		}
+	}
+
+	if rules, ok := result["rules"].([]interface{}); ok {
+		for i := range rules {
+			rule, ok := rules[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			setLocation(rule, module.Rules[i].Head.Location)
+			body, ok := rule["body"].([]interface{})
+			if !ok {
+				continue
+			}
+			for j := range body {
+				if expr, ok := body[j].(map[string]interface{}); ok {
+					setLocation(expr, module.Rules[i].Body[j].Location)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func setLocation(obj map[string]interface{}, loc *ast.Location) {
+	if loc == nil {
+		return
+	}
+	obj["location"] = map[string]interface{}{
+		"file": loc.File,
+		"row":  json.Number(strconv.Itoa(loc.Row)),
+		"col":  json.Number(strconv.Itoa(loc.Col)),
+	}
+}
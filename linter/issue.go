@@ -0,0 +1,334 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Issue represents a single violation reported by a lint rule.
+//
+// Issues are decoded from the strings and objects produced by the lint query.
+// A string is reported as the message of an issue without a location. Such
+// issues are identified by the query that produced them (e.g.,
+// "system.lint.deny"). For objects, the following keys are recognized:
+//
+//	message     string: description of the violation
+//	location    object: {"file": string, "row": number, "col": number,
+//	                     "end_row": number, "end_col": number}
+//	id          string: identifier of the lint rule
+//	severity    string: severity of the violation
+//
+// The message is required and the message, id, and severity must be strings.
+// The location is optional but strongly encouraged since issues without a
+// location cannot be attributed to a file. All other keys are preserved in
+// Extra. Missing or malformed positions are decoded as zero values, e.g., a
+// location with a negative row is reported without a row.
+//
+// If the Runner has failure lines enabled, Line contains the text of the
+// source line that the location refers to. If the Runner evaluates several
+// queries, Query contains the query that produced the issue.
+type Issue struct {
+	File     string                 `json:"file,omitempty"`
+	Row      int                    `json:"row,omitempty"`
+	Col      int                    `json:"col,omitempty"`
+	EndRow   int                    `json:"end_row,omitempty"`
+	EndCol   int                    `json:"end_col,omitempty"`
+	Message  string                 `json:"message"`
+	RuleID   string                 `json:"rule_id,omitempty"`
+	Severity string                 `json:"severity,omitempty"`
+	Extra    map[string]interface{} `json:"extra,omitempty"`
+	Line     string                 `json:"line,omitempty"`
+	Query    string                 `json:"query,omitempty"`
+}
+
+// key returns a string that identifies the finding that issue reports.
+func (issue Issue) key() string {
+	return fmt.Sprintf("%q:%d:%d:%d:%d:%q:%q", issue.File, issue.Row, issue.Col, issue.EndRow, issue.EndCol, issue.Message, issue.Level())
+}
+
+// Severity levels of issues. Issues with the "warning" severity have the
+// SeverityWarn level and issues without a severity have the SeverityError
+// level. Lint normalizes the "warning" severity to SeverityWarn and unknown
+// severities to SeverityError.
+const (
+	SeverityError = "error"
+	SeverityWarn  = "warn"
+	SeverityInfo  = "info"
+)
+
+// Severities contains the severity levels from highest to lowest.
+var Severities = []string{SeverityError, SeverityWarn, SeverityInfo}
+
+// Level returns the severity level of the issue.
+func (issue Issue) Level() string {
+	switch issue.Severity {
+	case "warn", "warning":
+		return SeverityWarn
+	case "info":
+		return SeverityInfo
+	}
+	return SeverityError
+}
+
+// normalizeSeverities replaces the severities of issues that have one with
+// their levels, e.g., "warning" with SeverityWarn, so that reports contain
+// only one spelling of each level.
+func normalizeSeverities(issues []Issue) []Issue {
+	for i := range issues {
+		if issues[i].Severity != "" {
+			issues[i].Severity = issues[i].Level()
+		}
+	}
+	return issues
+}
+
+// knownSeverity returns true if severity is empty or one of the severities
+// recognized by Level.
+func knownSeverity(severity string) bool {
+	switch severity {
+	case "", SeverityError, SeverityWarn, "warning", SeverityInfo:
+		return true
+	}
+	return false
+}
+
+// atLeast returns true if severity level a is equal to or higher than b.
+func atLeast(a, b string) bool {
+	return severityRank(a) >= severityRank(b)
+}
+
+func severityRank(level string) int {
+	switch level {
+	case SeverityInfo:
+		return 0
+	case SeverityWarn:
+		return 1
+	}
+	return 2
+}
+
+// newIssue returns an Issue decoded from a value produced by the lint query.
+// Strings are decoded as issues with a message only. If the value is not a
+// valid lint violation, the reason is returned as the error.
+func newIssue(x interface{}) (Issue, error) {
+
+	if msg, ok := x.(string); ok {
+		return Issue{Message: msg}, nil
+	}
+
+	obj, ok := x.(map[string]interface{})
+	if !ok {
+		return Issue{}, fmt.Errorf("violation must be a string or an object but got %v", valueType(x))
+	}
+
+	if _, ok := obj["message"]; !ok {
+		return Issue{}, fmt.Errorf(`violation must contain a "message"`)
+	}
+
+	var issue Issue
+
+	for key, value := range obj {
+		var ok bool
+		switch key {
+		case "message":
+			issue.Message, ok = value.(string)
+		case "location":
+			var loc map[string]interface{}
+			if loc, ok = value.(map[string]interface{}); ok {
+				issue.File, _ = loc["file"].(string)
+				issue.Row = decodePosition(loc["row"])
+				issue.Col = decodePosition(loc["col"])
+				issue.EndRow = decodePosition(loc["end_row"])
+				issue.EndCol = decodePosition(loc["end_col"])
+			}
+		case "id":
+			issue.RuleID, ok = value.(string)
+		case "severity":
+			issue.Severity, ok = value.(string)
+		default:
+			if issue.Extra == nil {
+				issue.Extra = map[string]interface{}{}
+			}
+			issue.Extra[key] = value
+			ok = true
+		}
+		if !ok {
+			want := "string"
+			if key == "location" {
+				want = "object"
+			}
+			return Issue{}, fmt.Errorf("violation %q must be %v %v but got %v", key, article(want), want, valueType(value))
+		}
+	}
+
+	return issue, nil
+}
+
+// ViolationError is returned by Lint if a lint rule produces a value that is
+// not a valid lint violation. Rules contains the locations of the rules of the
+// query that may have produced the value.
+type ViolationError struct {
+	Query  string
+	Value  interface{}
+	Reason string
+	Rules  []*ast.Location
+}
+
+func (err *ViolationError) Error() string {
+
+	msg := "linter configuration error: "
+
+	if len(err.Rules) > 0 {
+		locs := make([]string, len(err.Rules))
+		for i, loc := range err.Rules {
+			locs[i] = ruleLocation(loc)
+		}
+		msg += strings.Join(locs, ", ") + ": "
+	}
+
+	bs, _ := json.Marshal(err.Value)
+	value := string(bs)
+	if len(value) > 80 {
+		value = value[:77] + "..."
+	}
+
+	return fmt.Sprintf("%v%v produced an invalid violation: %v: %v", msg, err.Query, err.Reason, value)
+}
+
+// newViolationError returns a ViolationError for value produced by query.
+// The rules of query are narrowed down to those whose head could have
+// produced the value.
+func newViolationError(compiler *ast.Compiler, query string, value interface{}, reason error) *ViolationError {
+
+	err := &ViolationError{Query: query, Value: value, Reason: reason.Error()}
+
+	ref, parseErr := ast.ParseRef(query)
+	if parseErr != nil {
+		return err
+	}
+
+	for _, rule := range compiler.GetRulesExact(ref.GroundPrefix()) {
+		if rule.Head.Location != nil && mayProduce(rule, value) {
+			err.Rules = append(err.Rules, rule.Head.Location)
+		}
+	}
+
+	return err
+}
+
+// mayProduce returns true if the head of rule could produce value.
+func mayProduce(rule *ast.Rule, value interface{}) bool {
+
+	term := rule.Head.Key
+	if term == nil {
+		return true
+	}
+
+	switch v := term.Value.(type) {
+	case ast.Object:
+		obj, ok := value.(map[string]interface{})
+		if !ok || len(obj) != len(v) {
+			return false
+		}
+		for _, item := range v {
+			if key, ok := item[0].Value.(ast.String); ok {
+				if _, ok := obj[string(key)]; !ok {
+					return false
+				}
+			}
+		}
+		return true
+	case ast.String:
+		_, ok := value.(string)
+		return ok
+	case ast.Number:
+		_, ok := value.(json.Number)
+		return ok
+	case ast.Boolean:
+		_, ok := value.(bool)
+		return ok
+	case ast.Array:
+		_, ok := value.([]interface{})
+		return ok
+	}
+
+	return true
+}
+
+// queryRuleID returns the rule identifier of issues produced as strings by
+// query, i.e., the path of the query without the "data" prefix.
+func queryRuleID(query string) string {
+	return strings.TrimPrefix(query, ast.DefaultRootDocument.Value.String()+".")
+}
+
+// ruleLocation returns the file and line of a rule.
+func ruleLocation(loc *ast.Location) string {
+	if loc.File == "" {
+		return fmt.Sprintf("line %d", loc.Row)
+	}
+	return fmt.Sprintf("%v:%d", loc.File, loc.Row)
+}
+
+// valueType returns the JSON type of x.
+func valueType(x interface{}) string {
+	switch x.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+func article(noun string) string {
+	if strings.IndexAny(noun[:1], "aeiou") == 0 {
+		return "an"
+	}
+	return "a"
+}
+
+// decodePosition returns the row or column represented by x. If x is not a
+// positive integer, zero is returned.
+func decodePosition(x interface{}) int {
+	n, ok := x.(json.Number)
+	if !ok {
+		return 0
+	}
+	i, err := n.Int64()
+	if err != nil || i < 0 {
+		return 0
+	}
+	return int(i)
+}
+
+// sourceLine returns the text of line row (1-based) in src without the line
+// terminator. If src does not contain the line, false is returned.
+func sourceLine(src []byte, row int) (string, bool) {
+
+	if row <= 0 {
+		return "", false
+	}
+
+	lines := strings.SplitAfter(string(src), "\n")
+
+	if row > len(lines) || (row == len(lines) && lines[row-1] == "") {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[row-1], "\r\n"), true
+}
@@ -0,0 +1,199 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestRunnerLintIssues(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny[{
+	"message": "complete",
+	"location": {"file": "a.rego", "row": 3, "col": 5, "end_row": 4, "end_col": 1},
+	"id": "style/complete",
+	"severity": "warning",
+	"url": "https://example.com/complete",
+}] { true }
+
+deny[{"message": "no location"}] { true }
+
+deny[{"message": "no file", "location": {"row": 7}}] { true }
+
+deny[{"message": "negative row", "location": {"file": "b.rego", "row": -1, "col": 2}}] { true }
+
+deny[{"message": "bad row", "location": {"file": "d.rego", "row": "one"}}] { true }`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{
+		{File: "", Message: "no file", Row: 7, RuleID: "system.lint.deny"},
+		{File: "", Message: "no location", RuleID: "system.lint.deny"},
+		{File: "a.rego", Row: 3, Col: 5, EndRow: 4, EndCol: 1, Message: "complete", RuleID: "style/complete", Severity: SeverityWarn, Extra: map[string]interface{}{
+			"url": "https://example.com/complete",
+		}},
+		{File: "b.rego", Col: 2, Message: "negative row", RuleID: "system.lint.deny"},
+		{File: "d.rego", Message: "bad row", RuleID: "system.lint.deny"},
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Message < b.Message
+	})
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues:\n%v\n\nGot:\n%v", expected, report.Issues)
+	}
+}
+
+func TestRunnerLintStringIssue(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny["do not use http.send"] { true }
+
+deny[{"message": "object", "location": {"file": "a.rego", "row": 1}}] { true }
+
+warn[msg] { msg = "plain warning" }`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{
+		{Message: "do not use http.send", RuleID: "system.lint.deny"},
+		{Message: "plain warning", RuleID: "system.lint.warn", Severity: SeverityWarn},
+		{File: "a.rego", Row: 1, Message: "object", RuleID: "system.lint.deny"},
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues:\n%v\n\nGot:\n%v", expected, report.Issues)
+	}
+}
+
+func TestRunnerLintInvalidIssue(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		rules    string
+		expected string
+	}{
+		{
+			note: "number",
+			rules: `package system.lint
+
+deny[{"message": "ok"}] { true }
+
+deny[x] { x = 7 }`,
+			expected: `linter configuration error: lint.rego:5: data.system.lint.deny produced an invalid violation: violation must be a string or an object but got number: 7`,
+		},
+		{
+			note: "missing message",
+			rules: `package system.lint
+
+deny[{"message": "ok"}] { true }
+
+deny[{"msg": "oops", "id": "x"}] { true }`,
+			expected: `linter configuration error: lint.rego:5: data.system.lint.deny produced an invalid violation: violation must contain a "message": {"id":"x","msg":"oops"}`,
+		},
+		{
+			note: "bad location",
+			rules: `package system.lint
+
+deny[{"message": "bad location", "location": "c.rego:1"}] { true }`,
+			expected: `linter configuration error: lint.rego:3: data.system.lint.deny produced an invalid violation: violation "location" must be an object but got string: {"location":"c.rego:1","message":"bad location"}`,
+		},
+		{
+			note: "bad message",
+			rules: `package system.lint
+
+warn[{"message": 1}] { true }`,
+			expected: `linter configuration error: lint.rego:3: data.system.lint.warn produced an invalid violation: violation "message" must be a string but got number: {"message":1}`,
+		},
+	}
+
+	for _, tc := range tests {
+
+		modules := map[string]*ast.Module{
+			"lint.rego": mustParseModule("lint.rego", tc.rules),
+		}
+
+		ctx := context.Background()
+		runner := New().SetModules(modules)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+
+		if _, ok := err.(*ViolationError); !ok || report != nil {
+			t.Errorf("%v: expected violation error but got: %v", tc.note, err)
+		} else if err.Error() != tc.expected {
+			t.Errorf("%v: expected:\n%v\n\nGot:\n%v", tc.note, tc.expected, err)
+		}
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+
+	src := []byte("package a\r\n\np = true\nq = false")
+
+	tests := []struct {
+		row      int
+		expected string
+		ok       bool
+	}{
+		{0, "", false},
+		{1, "package a", true},
+		{2, "", true},
+		{3, "p = true", true},
+		{4, "q = false", true},
+		{5, "", false},
+	}
+
+	for _, tc := range tests {
+		line, ok := sourceLine(src, tc.row)
+		if line != tc.expected || ok != tc.ok {
+			t.Errorf("Expected row %d to be (%q, %v) but got (%q, %v)", tc.row, tc.expected, tc.ok, line, ok)
+		}
+	}
+
+	if _, ok := sourceLine([]byte("package a\n"), 2); ok {
+		t.Errorf("Expected row past trailing newline to be missing")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Package  string          `xml:"package,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// printJUnit prints report as JUnit XML. Each file is reported as a test
+// suite that contains a failed test case for each issue in the file. Files
+// without issues contain a single passing test case.
+func (r *Runner) printJUnit(report *Report) error {
+
+	files := r.lintedFiles()
+	name := r.suiteName
+	if name == "" {
+		name = junitSuiteName(files)
+	}
+
+	suites := junitTestSuites{Name: name}
+	index := map[string]int{}
+
+	for _, issue := range sortedIssues(report.Issues) {
+		i, ok := index[issue.File]
+		if !ok {
+			i = len(suites.Suites)
+			index[issue.File] = i
+			suites.Suites = append(suites.Suites, newJUnitTestSuite(name, issue.File))
+		}
+		suite := &suites.Suites[i]
+		suite.Cases = append(suite.Cases, newJUnitTestCase(issue))
+		suite.Tests++
+		suite.Failures++
+	}
+
+	for _, file := range files {
+		if _, ok := index[file]; !ok {
+			suite := newJUnitTestSuite(name, file)
+			suite.Cases = []junitTestCase{{Name: "lint", Classname: file}}
+			suite.Tests = 1
+			suites.Suites = append(suites.Suites, suite)
+		}
+	}
+
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+	}
+
+	bs, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.output, "%v%s\n", xml.Header, bs)
+	return err
+}
+
+func newJUnitTestSuite(name, file string) junitTestSuite {
+	if file == "" {
+		file = "(no file)"
+	}
+	return junitTestSuite{Name: file, Package: name}
+}
+
+func newJUnitTestCase(issue Issue) junitTestCase {
+
+	id := issue.RuleID
+	if id == "" {
+		id = "lint"
+	}
+
+	name := id
+	if issue.Row > 0 {
+		name = fmt.Sprintf("%v at line %d", id, issue.Row)
+	}
+
+	text := issue.Message
+	if issue.RuleID != "" {
+		text = "[" + issue.RuleID + "] " + text
+	}
+	if issue.Row > 0 {
+		text = fmt.Sprintf("%v:%d: %v", issue.File, issue.Row, text)
+	} else if issue.File != "" {
+		text = issue.File + ": " + text
+	}
+
+	return junitTestCase{
+		Name:      name,
+		Classname: issue.File,
+		Failure: &junitFailure{
+			Message: issue.Message,
+			Type:    issue.RuleID,
+			Text:    text,
+		},
+	}
+}
+
+// junitSuiteName returns the name of the top-level directory that contains
+// files. If the files do not share a directory, "opa lint" is returned.
+func junitSuiteName(files []string) string {
+
+	if len(files) == 0 {
+		return "opa lint"
+	}
+
+	dir := path.Dir(filepath.ToSlash(files[0]))
+
+	for _, file := range files[1:] {
+		file = filepath.ToSlash(file)
+		for dir != "." && dir != "/" && !strings.HasPrefix(file, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+
+	if dir == "." || dir == "/" {
+		return "opa lint"
+	}
+
+	return path.Base(dir)
+}
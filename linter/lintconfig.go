@@ -0,0 +1,236 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// LintConfigFile is the name of the file that contains the settings of the
+// lint command.
+const LintConfigFile = ".opa-lint.yaml"
+
+// LintConfig contains the settings of the lint command that can be kept in
+// a LintConfigFile, e.g.:
+//
+//	severity:
+//	  no-todo-comments: warn
+//	  unsafe-builtin: error
+//	disable:
+//	  - style/*
+//	ignore:
+//	  - gen/*
+//
+// Severity maps rule identifiers to the severity level of their issues (see
+// SetSeverities). Disable contains glob patterns for rules whose issues are
+// dropped (see SetDisabledRules). Ignore contains glob patterns for files
+// that are not linted (see SetIgnore). Ignore patterns are matched against
+// file names relative to the directory that the settings apply to (see
+// SetLintConfigs).
+//
+// Dirs is only set on the settings returned by EffectiveLintConfig and lists
+// the directories whose settings were merged.
+type LintConfig struct {
+	Severity map[string]string `json:"severity,omitempty"`
+	Disable  []string          `json:"disable,omitempty"`
+	Ignore   []string          `json:"ignore,omitempty"`
+	Dirs     []string          `json:"dirs,omitempty"`
+}
+
+// ParseLintConfig parses the lint settings contained in bs. The settings are
+// read from file, which is only used in error messages.
+func ParseLintConfig(file string, bs []byte) (*LintConfig, error) {
+
+	var config LintConfig
+
+	if err := yaml.Unmarshal(bs, &config); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	return &config, nil
+}
+
+func (config *LintConfig) validate() error {
+
+	if err := validateSeverities(config.Severity); err != nil {
+		return err
+	}
+
+	if err := rulePatterns(config.Disable).validate(); err != nil {
+		return err
+	}
+
+	return ignorePatterns(config.Ignore).validate()
+}
+
+// SetLintConfigs sets the lint settings of directories, keyed by directory
+// name. The settings of a directory apply to the files in the directory and
+// its subdirectories. The settings keyed by "" apply to all files. If several
+// directories contain a file, their settings are merged from the root down:
+// severity overrides of deeper directories take precedence and disabled rules
+// and ignore patterns are combined. Overrides set with SetSeverities,
+// SetDisabledRules, and SetIgnore apply in addition to the settings and take
+// precedence over them. The settings are validated by Compile.
+func (r *Runner) SetLintConfigs(configs map[string]*LintConfig) *Runner {
+	r.lintConfigs = configs
+	return r
+}
+
+// EffectiveLintConfig returns the lint settings that apply to the file named
+// file (see SetLintConfigs).
+func (r *Runner) EffectiveLintConfig(file string) *LintConfig {
+
+	effective := &LintConfig{}
+
+	for _, dir := range r.lintConfigDirs(file) {
+		config := r.lintConfigs[dir]
+		for id, level := range config.Severity {
+			if effective.Severity == nil {
+				effective.Severity = map[string]string{}
+			}
+			effective.Severity[id] = level
+		}
+		effective.Disable = appendUnique(effective.Disable, config.Disable...)
+		effective.Ignore = appendUnique(effective.Ignore, config.Ignore...)
+		effective.Dirs = append(effective.Dirs, dir)
+	}
+
+	return effective
+}
+
+// PrintLintConfigs prints the lint settings that apply to each of the files
+// provided to the lint rules (see EffectiveLintConfig). This is helpful when
+// debugging the settings of nested directories.
+func (r *Runner) PrintLintConfigs() error {
+
+	configs := map[string]*LintConfig{}
+
+	for _, file := range r.lintedFiles() {
+		configs[file] = r.EffectiveLintConfig(file)
+	}
+
+	bs, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(r.output, string(bs))
+	return err
+}
+
+// lintConfigDirs returns the directories whose settings apply to the file
+// named file, from the root down. Issues that are not located in a file are
+// only subject to the settings keyed by "".
+func (r *Runner) lintConfigDirs(file string) []string {
+
+	var dirs []string
+
+	for dir, config := range r.lintConfigs {
+		if config == nil {
+			continue
+		}
+		if dir == "" || (file != "" && isUnderDir(file, dir)) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		if len(dirs[i]) != len(dirs[j]) {
+			return len(dirs[i]) < len(dirs[j])
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	return dirs
+}
+
+// ignored returns true if the file named file must not be linted because it
+// matches an ignore pattern set with SetIgnore or in the settings of one of
+// its directories.
+func (r *Runner) ignored(file string) bool {
+
+	if r.ignore.Match(file) {
+		return true
+	}
+
+	for _, dir := range r.lintConfigDirs(file) {
+		if ignorePatterns(r.lintConfigs[dir].Ignore).Match(relativeToDir(file, dir)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isUnderDir returns true if file is contained in dir or one of its
+// subdirectories. The directory "." contains all relative file names.
+func isUnderDir(file, dir string) bool {
+	dir = strings.TrimSuffix(path.Clean(filepath.ToSlash(dir)), "/")
+	if dir == "." {
+		return !filepath.IsAbs(file)
+	}
+	return strings.HasPrefix(filepath.ToSlash(file), dir+"/")
+}
+
+// relativeToDir returns the name of file relative to dir, which contains it.
+func relativeToDir(file, dir string) string {
+	file = filepath.ToSlash(file)
+	if dir == "" {
+		return file
+	}
+	dir = strings.TrimSuffix(path.Clean(filepath.ToSlash(dir)), "/")
+	if dir == "." {
+		return path.Clean(file)
+	}
+	return strings.TrimPrefix(file, dir+"/")
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, x := range list {
+			if x == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// validateSeverities returns an error if one of the levels in overrides is
+// not a severity level.
+func validateSeverities(overrides map[string]string) error {
+
+	ids := make([]string, 0, len(overrides))
+
+	for id := range overrides {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if level := overrides[id]; level == "" || !knownSeverity(level) {
+			return fmt.Errorf("invalid severity for rule %v: %q", id, level)
+		}
+	}
+
+	return nil
+}
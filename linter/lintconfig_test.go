@@ -0,0 +1,155 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestParseLintConfig(t *testing.T) {
+
+	config, err := ParseLintConfig(LintConfigFile, []byte(`severity:
+  no-todo-comments: warn
+  unsafe-builtin: error
+  style/naming: info
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"no-todo-comments": SeverityWarn,
+		"unsafe-builtin":   SeverityError,
+		"style/naming":     SeverityInfo,
+	}
+
+	if !reflect.DeepEqual(config.Severity, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, config.Severity)
+	}
+
+	if config, err := ParseLintConfig(LintConfigFile, nil); err != nil || len(config.Severity) != 0 {
+		t.Fatalf("Expected empty config but got: %v (err: %v)", config, err)
+	}
+}
+
+func TestParseLintConfigErrors(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		input    string
+		expected string
+	}{
+		{"bad yaml", "severity: [", LintConfigFile + ":"},
+		{"bad level", "severity:\n  no-todo-comments: critical", `invalid severity for rule no-todo-comments: "critical"`},
+		{"empty level", "severity:\n  no-todo-comments: ''", `invalid severity for rule no-todo-comments: ""`},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseLintConfig(LintConfigFile, []byte(tc.input))
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: Expected error containing %q but got: %v", tc.note, tc.expected, err)
+		}
+	}
+}
+
+func TestRunnerLintSettings(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint/rules.rego": ast.MustParseModule(`package system.lint
+
+deny[{"id": "no-foo", "message": "foo", "location": {"file": file}}] { input.modules[file] }
+deny[{"id": "style/naming", "message": "naming", "location": {"file": file}}] { input.modules[file] }`),
+		"platform/a.rego":         ast.MustParseModule(`package a`),
+		"teams/x/b.rego":          ast.MustParseModule(`package b`),
+		"teams/x/gen/c.rego":      ast.MustParseModule(`package c`),
+		"teams/x/gen/d_test.rego": ast.MustParseModule(`package d`),
+	}
+
+	configs := map[string]*LintConfig{
+		"": {
+			Severity: map[string]string{"no-foo": SeverityWarn},
+			Ignore:   []string{"*_test.rego"},
+		},
+		"platform": {
+			Severity: map[string]string{"no-foo": SeverityError},
+		},
+		"teams/x": {
+			Disable: []string{"style/*"},
+			Ignore:  []string{"gen/*"},
+		},
+		"lint": nil,
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetIgnore([]string{"lint"}).SetLintConfigs(configs)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	result := map[string]string{}
+
+	for _, issue := range report.Issues {
+		result[issue.File+" "+issue.RuleID] = issue.Severity
+	}
+
+	expected := map[string]string{
+		"platform/a.rego no-foo":       SeverityError,
+		"platform/a.rego style/naming": "",
+		"teams/x/b.rego no-foo":        SeverityWarn,
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, result)
+	}
+
+	if report.Summary.Disabled != 1 {
+		t.Fatalf("Expected 1 disabled issue but got: %+v", report.Summary)
+	}
+
+	effective := runner.EffectiveLintConfig("teams/x/b.rego")
+	expectedConfig := &LintConfig{
+		Severity: map[string]string{"no-foo": SeverityWarn},
+		Disable:  []string{"style/*"},
+		Ignore:   []string{"*_test.rego", "gen/*"},
+		Dirs:     []string{"", "teams/x"},
+	}
+
+	if !reflect.DeepEqual(effective, expectedConfig) {
+		t.Fatalf("Expected %+v but got: %+v", expectedConfig, effective)
+	}
+}
+
+func TestRunnerLintSettingsCurrentDir(t *testing.T) {
+
+	runner := New().SetLintConfigs(map[string]*LintConfig{
+		".": {Disable: []string{"no-foo"}},
+	})
+
+	if dirs := runner.EffectiveLintConfig("a/b.rego").Dirs; !reflect.DeepEqual(dirs, []string{"."}) {
+		t.Fatalf("Expected settings of current directory to apply but got: %v", dirs)
+	}
+
+	if dirs := runner.EffectiveLintConfig("/abs/b.rego").Dirs; len(dirs) != 0 {
+		t.Fatalf("Expected settings of current directory not to apply but got: %v", dirs)
+	}
+}
+
+func TestRunnerCompileBadLintSettings(t *testing.T) {
+	runner := New().SetLintConfigs(map[string]*LintConfig{"a": {Disable: []string{"[a-"}}})
+	if err := runner.Compile(context.Background()); err == nil || !strings.Contains(err.Error(), "lint settings of a") {
+		t.Fatalf("Expected error for bad lint settings but got: %v", err)
+	}
+}
@@ -0,0 +1,804 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// DefaultQuery is the query evaluated to obtain lint violations if no other
+// query is set on the Runner.
+const DefaultQuery = "data.system.lint.deny"
+
+// DefaultTimeout is the maximum amount of time that lint evaluation may take
+// if no other timeout is set on the Runner.
+const DefaultTimeout = 5 * time.Second
+
+// Report contains the output of a lint run. ExitReason explains whether the
+// run failed, either because it did not complete or because issues at or
+// above the Runner's fail-on level were found (see SetFailOn).
+//
+// If the report contains fewer issues than were found (see SetMaxIssues),
+// Truncated is true and TotalIssues contains the number of issues found. The
+// Summary always counts all issues that were found.
+//
+// If the Runner evaluates several queries and some of them fail, QueryErrors
+// describes the failures and Issues contains the issues of the other
+// queries.
+//
+// Diagnostics describes problems with the issues produced by the lint rules
+// that did not prevent them from being reported, e.g., unknown severities.
+// Notes contains information about the run that is only of interest when
+// debugging the lint settings, e.g., severity overrides that did not match
+// any issues.
+type Report struct {
+	Issues      []Issue       `json:"issues"`
+	Sample      *SampleReport `json:"sample,omitempty"`
+	Termination Termination   `json:"termination"`
+	Summary     Summary       `json:"summary"`
+	ExitReason  string        `json:"exit_reason"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	TotalIssues int           `json:"total_issues,omitempty"`
+	QueryErrors []QueryError  `json:"query_errors,omitempty"`
+	Diagnostics []string      `json:"diagnostics,omitempty"`
+	Notes       []string      `json:"notes,omitempty"`
+}
+
+// QueryError describes the failure of one of several lint queries (see
+// SetQueries).
+type QueryError struct {
+	Query   string `json:"query"`
+	Message string `json:"message"`
+}
+
+// Termination reasons reported by the Runner.
+const (
+	TerminationCompleted   = "completed"   // all modules were linted
+	TerminationTimeout     = "timeout"     // the Runner's timeout expired
+	TerminationInterrupted = "interrupted" // the caller cancelled the context
+)
+
+// Termination describes how a lint run ended. If the run did not complete,
+// Limit contains the limit that was reached (if any) and Unevaluated contains
+// the number of modules whose lint results are missing from the report.
+type Termination struct {
+	Reason      string `json:"reason"`
+	Limit       string `json:"limit,omitempty"`
+	Unevaluated int    `json:"unevaluated"`
+}
+
+// Summary contains the number of files and issues of a lint run. Issues that
+// are not reported (e.g., issues in ignored files) are not counted. Errors,
+// Warnings, and Infos count the issues with the SeverityError, SeverityWarn,
+// and SeverityInfo levels.
+// Failures counts the issues at or above the Runner's fail-on level (see
+// SetFailOn). Disabled counts the issues of disabled rules and Suppressed
+// counts the issues suppressed by IgnoreDirective comments. Neither are
+// counted otherwise (see SetDisabledRules).
+type Summary struct {
+	FilesScanned    int   `json:"files_scanned"`
+	FilesWithIssues int   `json:"files_with_issues"`
+	Errors          int   `json:"errors"`
+	Warnings        int   `json:"warnings"`
+	Infos           int   `json:"infos"`
+	Failures        int   `json:"failures"`
+	Disabled        int   `json:"disabled"`
+	Suppressed      int   `json:"suppressed"`
+	DurationMS      int64 `json:"duration_ms"`
+}
+
+// Runner evaluates lint rules against a set of policy modules.
+type Runner struct {
+	modules       map[string]*ast.Module
+	rules         map[string]*ast.Module
+	compiler      *ast.Compiler
+	store         *storage.Storage
+	query         string
+	sample        *Sample
+	ignore        ignorePatterns
+	filter        string
+	filterRe      *regexp.Regexp
+	sources       map[string][]byte
+	configs       map[string][]byte
+	parsed        map[string]interface{}
+	lines         bool
+	color         bool
+	output        io.Writer
+	suiteName     string
+	markdown      MarkdownOptions
+	template      string
+	tmpl          *template.Template
+	timeout       time.Duration
+	disabled      map[string]struct{}
+	enabledRules  rulePatterns
+	disabledRules rulePatterns
+	severities    map[string]string
+	lintConfigs   map[string]*LintConfig
+	failOn        string
+	maxIssues     int
+	queries       []string
+	discover      bool
+	evalQueries   []string
+	compileNotes  []string
+}
+
+// New returns a new Runner that evaluates the default lint query.
+func New() *Runner {
+	return &Runner{
+		query:   DefaultQuery,
+		output:  os.Stdout,
+		timeout: DefaultTimeout,
+	}
+}
+
+// SetModules sets the modules to lint. The modules are keyed by file name. If
+// the lint rules are not set with SetLintModules, the modules must include
+// the lint rules themselves, in which case the lint rules are also linted.
+func (r *Runner) SetModules(modules map[string]*ast.Module) *Runner {
+	r.modules = modules
+	return r
+}
+
+// SetLintModules sets the modules that contain the lint rules. The modules are
+// keyed by file name. Lint modules are compiled along with the modules being
+// linted but they are not provided to the lint rules and issues located in
+// them are not reported.
+func (r *Runner) SetLintModules(modules map[string]*ast.Module) *Runner {
+	r.rules = modules
+	return r
+}
+
+// SetStore sets the storage layer that lint rules are evaluated against. If
+// the store is not set, lint rules are evaluated against an empty store and the
+// transaction passed to Lint is ignored.
+func (r *Runner) SetStore(store *storage.Storage) *Runner {
+	r.store = store
+	return r
+}
+
+// SetQuery sets the query evaluated to obtain lint violations. See Lint for
+// the queries evaluated in addition to a query that refers to a "deny" rule.
+func (r *Runner) SetQuery(query string) *Runner {
+	r.query = query
+	return r
+}
+
+// EnableAutoDiscover controls whether Compile replaces a query that does not
+// refer to any rules with the "deny" rule of the only package of the lint
+// modules that defines lint rules (see LintPackages). If auto-discovery is
+// disabled or more than one package defines such rules, Compile returns a
+// DiscoveryError instead. Packages of the lint modules that define lint rules
+// but are not evaluated by any query are listed in the report's Notes.
+func (r *Runner) EnableAutoDiscover(enabled bool) *Runner {
+	r.discover = enabled
+	return r
+}
+
+// SetQueries sets several queries to evaluate instead of the query set with
+// SetQuery. The queries are evaluated against the same input and issues
+// found by more than one query are reported once. Each issue is tagged with
+// the query that found it. If some of the queries fail, Lint reports the
+// issues of the others (see Report). Queries set with SetQueries are not
+// subject to auto-discovery.
+func (r *Runner) SetQueries(queries []string) *Runner {
+	r.queries = queries
+	return r
+}
+
+// Query returns the query evaluated by Lint. After Compile, the query differs
+// from the query set with SetQuery if it was discovered (see
+// EnableAutoDiscover). If several queries are set, the first is returned.
+func (r *Runner) Query() string {
+	if queries := r.Queries(); len(queries) > 0 {
+		return queries[0]
+	}
+	return ""
+}
+
+// Queries returns the queries evaluated by Lint.
+func (r *Runner) Queries() []string {
+	if r.compiler != nil {
+		return r.evalQueries
+	}
+	if len(r.queries) > 0 {
+		return r.queries
+	}
+	return []string{r.query}
+}
+
+// SetTimeout sets the maximum amount of time that lint evaluation may take. If
+// the timeout is zero, evaluation is only limited by the context passed to
+// Lint.
+func (r *Runner) SetTimeout(timeout time.Duration) *Runner {
+	r.timeout = timeout
+	return r
+}
+
+// DisableBuiltin disables the built-in check identified by id, e.g.,
+// BuiltinConflictingDefault.
+func (r *Runner) DisableBuiltin(id string) *Runner {
+	if r.disabled == nil {
+		r.disabled = map[string]struct{}{}
+	}
+	r.disabled[id] = struct{}{}
+	return r
+}
+
+// SetEnabledRules sets glob patterns for the identifiers of the rules whose
+// issues are reported, e.g., "style/*". If enabled rules are set, issues of
+// all other rules are dropped. Issues without an explicit identifier are
+// identified by the rule that produced them (e.g., "system.lint.deny"). The
+// patterns are validated by Compile.
+func (r *Runner) SetEnabledRules(patterns []string) *Runner {
+	r.enabledRules = rulePatterns(patterns)
+	return r
+}
+
+// SetDisabledRules sets glob patterns for the identifiers of the rules whose
+// issues are dropped. Disabled rules take precedence over enabled rules (see
+// SetEnabledRules). Dropped issues are not reported or counted as failures
+// but the report's Summary records how many were dropped.
+func (r *Runner) SetDisabledRules(patterns []string) *Runner {
+	r.disabledRules = rulePatterns(patterns)
+	return r
+}
+
+// SetSeverities sets the severity levels of the issues of rules, keyed by
+// rule identifier (see SetEnabledRules). The overrides take precedence over
+// the severities set by the lint rules and are applied before the issues are
+// counted as failures (see SetFailOn). Overrides for rules that do not
+// produce any issues are listed in the report's Notes. The levels are
+// validated by Compile.
+func (r *Runner) SetSeverities(overrides map[string]string) *Runner {
+	r.severities = overrides
+	return r
+}
+
+// SetSources sets the raw source of the modules, keyed by file name. The
+// sources are used to attach source lines to issues, see EnableFailureLine.
+func (r *Runner) SetSources(sources map[string][]byte) *Runner {
+	r.sources = sources
+	return r
+}
+
+// SetConfigs sets OPA configuration files to provide to the lint rules, keyed
+// by file name. The configuration files are parsed by Compile and provided
+// under input.opa_configs. Configuration files that match an ignore pattern
+// are not provided.
+func (r *Runner) SetConfigs(configs map[string][]byte) *Runner {
+	r.configs = configs
+	return r
+}
+
+// EnableFailureLine controls whether issues include the text of the source
+// line that their location refers to. Only the first line of multi-line
+// locations is included. Issues located in files without a source (see
+// SetSources) or at rows past the end of the file do not include a line.
+func (r *Runner) EnableFailureLine(enabled bool) *Runner {
+	r.lines = enabled
+	return r
+}
+
+// SetFailOn sets the lowest severity level (see Severities) of issues that
+// fail the lint run. Failing issues are counted in the report's Summary and
+// explained by its ExitReason. The default is SeverityError.
+func (r *Runner) SetFailOn(level string) *Runner {
+	r.failOn = level
+	return r
+}
+
+// SetMaxIssues sets the maximum number of issues included in reports. If more
+// issues are found, the issues with the highest severity are kept. Zero
+// means no limit, which is the default.
+func (r *Runner) SetMaxIssues(n int) *Runner {
+	r.maxIssues = n
+	return r
+}
+
+// EnableColor controls whether the pretty format colorizes its output with ANSI
+// escape sequences. Other formats are never colorized.
+func (r *Runner) EnableColor(enabled bool) *Runner {
+	r.color = enabled
+	return r
+}
+
+// SetSuiteName sets the name reported for the test suites in JUnit reports.
+// By default, the name of the top-level directory of the linted files is
+// used.
+func (r *Runner) SetSuiteName(name string) *Runner {
+	r.suiteName = name
+	return r
+}
+
+// SetMarkdownOptions sets the options used to print Markdown reports.
+func (r *Runner) SetMarkdownOptions(opts MarkdownOptions) *Runner {
+	r.markdown = opts
+	return r
+}
+
+// SetTemplate sets the text/template used to print reports in the
+// go-template format. The template is executed against TemplateData and can
+// use the "json", "upper", and "relpath" functions in addition to the
+// text/template builtins. The template is parsed by Compile.
+func (r *Runner) SetTemplate(text string) *Runner {
+	r.template = text
+	return r
+}
+
+// SetOutput sets the writer that the Runner prints to. The default is
+// os.Stdout.
+func (r *Runner) SetOutput(w io.Writer) *Runner {
+	r.output = w
+	return r
+}
+
+// SetIgnore sets glob patterns for module file names that must not be linted.
+// Matching modules are still compiled so that references to them resolve,
+// but they are not provided to the lint rules and issues located in them are
+// not reported. A pattern without a path separator matches any element of the
+// file name, e.g., "*_test.rego" or "vendor". A pattern with a path separator
+// matches the file name or any of its parent directories, e.g., "gen/*".
+func (r *Runner) SetIgnore(patterns []string) *Runner {
+	r.ignore = ignorePatterns(patterns)
+	return r
+}
+
+// Filter restricts the modules provided to the lint rules to those whose file
+// names match the regular expression regex. The expression is not anchored,
+// e.g., "authz/" matches "policies/authz/a.rego". All modules are still
+// compiled so that references between modules resolve. The expression is
+// validated by Compile.
+func (r *Runner) Filter(regex string) *Runner {
+	r.filter = regex
+	return r
+}
+
+// SetSample restricts the modules provided to the lint rules to the subset
+// selected by sample. Ignored modules are excluded before sampling. All
+// modules are still compiled so that references between modules resolve. Lint
+// rules that aggregate over all of the modules will only see the sampled
+// modules; the report records the sample so that consumers can tell that the
+// results are partial.
+func (r *Runner) SetSample(sample *Sample) *Runner {
+	r.sample = sample
+	return r
+}
+
+// Compile compiles the modules set on the Runner. Compile must be called
+// before Lint.
+func (r *Runner) Compile(ctx context.Context) error {
+
+	if r.sample != nil {
+		if err := r.sample.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.ignore.validate(); err != nil {
+		return err
+	}
+
+	for id := range r.disabled {
+		if !isBuiltinCheck(id) {
+			return fmt.Errorf("unknown built-in check: %v", id)
+		}
+	}
+
+	if err := r.enabledRules.validate(); err != nil {
+		return err
+	}
+
+	if err := r.disabledRules.validate(); err != nil {
+		return err
+	}
+
+	if err := validateSeverities(r.severities); err != nil {
+		return err
+	}
+
+	for dir, config := range r.lintConfigs {
+		if config == nil {
+			continue
+		}
+		if err := config.validate(); err != nil {
+			return fmt.Errorf("lint settings of %v: %v", displayDir(dir), err)
+		}
+	}
+
+	if r.failOn != "" && r.failOn != SeverityError && r.failOn != SeverityWarn && r.failOn != SeverityInfo {
+		return fmt.Errorf("invalid fail-on severity: %v", r.failOn)
+	}
+
+	if r.maxIssues < 0 {
+		return fmt.Errorf("invalid maximum number of issues: %d", r.maxIssues)
+	}
+
+	r.tmpl = nil
+
+	if r.template != "" {
+		tmpl, err := parseTemplate(r.template)
+		if err != nil {
+			return err
+		}
+		r.tmpl = tmpl
+	}
+
+	r.filterRe = nil
+
+	if r.filter != "" {
+		re, err := regexp.Compile(r.filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %v", err)
+		}
+		r.filterRe = re
+	}
+
+	r.parsed = nil
+
+	if r.configs != nil {
+		r.parsed = map[string]interface{}{}
+		for file, bs := range r.configs {
+			config, err := configToInput(file, bs)
+			if err != nil {
+				return err
+			}
+			r.parsed[file] = config
+		}
+	}
+
+	modules := r.modules
+
+	if len(r.rules) > 0 {
+		modules = make(map[string]*ast.Module, len(r.modules)+len(r.rules))
+		for id, module := range r.modules {
+			modules[id] = module
+		}
+		for id, module := range r.rules {
+			if _, ok := modules[id]; ok {
+				return fmt.Errorf("%v: module is both a lint module and a module to lint", id)
+			}
+			modules[id] = module
+		}
+	}
+
+	compiler := ast.NewCompiler()
+
+	if compiler.Compile(modules); compiler.Failed() {
+		return compiler.Errors
+	}
+
+	queries := r.queries
+
+	if len(queries) == 0 {
+		query, err := r.discoverQuery(compiler)
+		if err != nil {
+			return err
+		}
+		queries = []string{query}
+	}
+
+	r.compiler = compiler
+	r.evalQueries = queries
+	r.compileNotes = r.unusedLintRules(queries)
+
+	return nil
+}
+
+// PrintParsed prints the input document that Lint provides to the lint rules,
+// i.e., the parsed modules that are not ignored or excluded by sampling. This
+// is helpful when writing lint rules.
+func (r *Runner) PrintParsed(ctx context.Context) error {
+
+	modules, _ := r.targets()
+
+	input, err := buildInput(modules, r.targetConfigs())
+	if err != nil {
+		return err
+	}
+
+	bs, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(r.output, string(bs))
+	return err
+}
+
+// Lint evaluates the lint query against the compiled modules and returns the
+// issues that were found sorted by file and position. If the query refers to a
+// "deny" rule, the "warn" and "info" rules in the same package are evaluated
+// as well and their issues default to the SeverityWarn and SeverityInfo
+// levels. If a query is undefined, it produces no issues. If evaluation
+// fails, the error is returned and no report is produced, unless evaluation
+// was cut short by the timeout or by ctx ending. In that case, Lint returns
+// the error along with a partial report whose Termination describes why the
+// run ended. If ctx ended, the error is ctx.Err(), even if the deadline of ctx
+// expired before the timeout.
+func (r *Runner) Lint(ctx context.Context, txn storage.Transaction) (*Report, error) {
+
+	if r.compiler == nil {
+		return nil, fmt.Errorf("modules must be compiled before linting")
+	}
+
+	start := time.Now()
+	modules, sample := r.targets()
+	configs := r.targetConfigs()
+
+	input, err := buildInput(modules, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []func(*rego.Rego){
+		rego.Compiler(r.compiler),
+		rego.Input(input),
+	}
+
+	if r.store != nil {
+		args = append(args, rego.Storage(r.store), rego.Transaction(txn))
+	}
+
+	parent := ctx
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	report := &Report{
+		Issues: []Issue{},
+		Sample: sample,
+		Termination: Termination{
+			Reason: TerminationCompleted,
+		},
+	}
+
+	unknown := map[string]int{}
+
+	finish := func() {
+		var disabled, suppressed int
+		var diagnostics, notes []string
+		report.Issues, report.Notes = r.overrideSeverities(report.Issues)
+		report.Issues = normalizeSeverities(report.Issues)
+		report.Issues, suppressed, diagnostics, notes = r.suppressIssues(report.Issues, modules, configs)
+		report.Issues, disabled = r.filterRules(report.Issues)
+		report.Issues = sortedIssues(report.Issues)
+		report.Diagnostics = append(severityDiagnostics(unknown), diagnostics...)
+		report.Notes = append(append(report.Notes, r.compileNotes...), notes...)
+		report.Summary = summarize(report.Issues, len(modules)+len(configs), r.failOnLevel(), time.Since(start))
+		report.Summary.Disabled = disabled
+		report.Summary.Suppressed = suppressed
+		report.ExitReason = exitReason(report, r.failOnLevel())
+		if r.maxIssues > 0 && len(report.Issues) > r.maxIssues {
+			report.Truncated = true
+			report.TotalIssues = len(report.Issues)
+			report.Issues = truncateIssues(report.Issues, r.maxIssues)
+		}
+	}
+
+	for _, builtin := range builtinChecks {
+		if _, ok := r.disabled[builtin.id]; !ok {
+			for _, issue := range builtin.check(modules) {
+				report.Issues = append(report.Issues, r.withLine(issue))
+			}
+		}
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, query := range r.evalQueries {
+
+		issues, err := r.eval(ctx, query, args)
+
+		if err != nil {
+			switch {
+			case r.timeout > 0 && parent.Err() == nil && ctx.Err() == context.DeadlineExceeded:
+				report.Termination = Termination{
+					Reason:      TerminationTimeout,
+					Limit:       r.timeout.String(),
+					Unevaluated: len(modules),
+				}
+				finish()
+				return report, fmt.Errorf("lint evaluation timed out after %v", r.timeout)
+			case ctx.Err() != nil:
+				report.Termination = Termination{
+					Reason:      TerminationInterrupted,
+					Unevaluated: len(modules),
+				}
+				finish()
+				return report, parent.Err()
+			case len(r.evalQueries) == 1:
+				return nil, err
+			}
+			report.QueryErrors = append(report.QueryErrors, QueryError{Query: query, Message: err.Error()})
+			continue
+		}
+
+		for _, issue := range issues {
+			if len(r.evalQueries) > 1 {
+				key := issue.key()
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				issue.Query = query
+			}
+			if !knownSeverity(issue.Severity) {
+				unknown[issue.Severity]++
+				issue.Severity = SeverityError
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	finish()
+
+	if n := len(report.QueryErrors); n > 0 {
+		return report, fmt.Errorf("%d of %d lint queries failed: %v: %v", n, len(r.evalQueries), report.QueryErrors[0].Query, report.QueryErrors[0].Message)
+	}
+
+	return report, nil
+}
+
+// eval evaluates the entrypoints of query and returns the issues that are
+// reported.
+func (r *Runner) eval(ctx context.Context, query string, args []func(*rego.Rego)) ([]Issue, error) {
+
+	issues := []Issue{}
+
+	for _, entry := range entrypoints(query) {
+
+		rs, err := rego.New(append(args, rego.Query(entry.query))...).Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range rs {
+			for _, expr := range result.Expressions {
+				values, ok := expr.Value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%v must produce a set or array but got %T", entry.query, expr.Value)
+				}
+				for _, value := range values {
+					issue, err := newIssue(value)
+					if err != nil {
+						return nil, newViolationError(r.compiler, entry.query, value, err)
+					}
+					if issue.RuleID == "" {
+						issue.RuleID = queryRuleID(entry.query)
+					}
+					if issue.File != "" && r.ignored(issue.File) {
+						continue
+					}
+					if _, ok := r.rules[issue.File]; ok {
+						continue
+					}
+					if issue.Severity == "" {
+						issue.Severity = entry.severity
+					}
+					issues = append(issues, r.withLine(issue))
+				}
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+func (r *Runner) failOnLevel() string {
+	if r.failOn == "" {
+		return SeverityError
+	}
+	return r.failOn
+}
+
+// entrypoint is a query evaluated to obtain issues. Issues produced by the
+// query without a severity are assigned severity.
+type entrypoint struct {
+	query    string
+	severity string
+}
+
+// entrypoints returns the queries evaluated by Lint for query. If query
+// refers to a "deny" rule, the "warn" and "info" rules in the same package
+// are evaluated as well. Rules that are not defined produce no issues.
+func entrypoints(query string) []entrypoint {
+
+	entries := []entrypoint{{query: query}}
+
+	ref, err := ast.ParseRef(query)
+	if err != nil || len(ref) <= 1 || !ref[len(ref)-1].Equal(ast.StringTerm("deny")) {
+		return entries
+	}
+
+	for _, level := range []string{SeverityWarn, SeverityInfo} {
+		sibling := ref[:len(ref)-1].Copy().Append(ast.StringTerm(level))
+		entries = append(entries, entrypoint{query: sibling.String(), severity: level})
+	}
+
+	return entries
+}
+
+// withLine returns issue with the source line attached if failure lines are
+// enabled.
+func (r *Runner) withLine(issue Issue) Issue {
+	if r.lines && issue.File != "" {
+		if src, ok := r.sources[issue.File]; ok {
+			issue.Line, _ = sourceLine(src, issue.Row)
+		}
+	}
+	return issue
+}
+
+// targets returns the modules to provide to the lint rules. Ignored modules
+// and modules that do not match the filter are excluded before sampling.
+func (r *Runner) targets() (map[string]*ast.Module, *SampleReport) {
+
+	ids := make([]string, 0, len(r.modules))
+
+	for id := range r.modules {
+		if r.ignored(id) {
+			continue
+		}
+		if r.filterRe != nil && !r.filterRe.MatchString(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	var sample *SampleReport
+
+	if r.sample != nil {
+		total := len(ids)
+		ids = r.sample.ids(ids)
+		sample = &SampleReport{
+			Fraction: r.sample.Fraction,
+			Count:    r.sample.Count,
+			Seed:     r.sample.Seed,
+			Sampled:  len(ids),
+			Total:    total,
+		}
+	}
+
+	modules := make(map[string]*ast.Module, len(ids))
+
+	for _, id := range ids {
+		modules[id] = r.modules[id]
+	}
+
+	return modules, sample
+}
+
+// targetConfigs returns the parsed configuration files to provide to the lint
+// rules.
+func (r *Runner) targetConfigs() map[string]interface{} {
+
+	if r.parsed == nil {
+		return nil
+	}
+
+	configs := make(map[string]interface{}, len(r.parsed))
+
+	for file, config := range r.parsed {
+		if !r.ignored(file) {
+			configs[file] = config
+		}
+	}
+
+	return configs
+}
@@ -0,0 +1,869 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/topdown"
+)
+
+const testLintRules = `package system.lint
+
+deny[{"message": msg, "location": loc}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+	loc = rule.location
+	msg = "rules must not be named foo"
+}`
+
+func TestRunnerLint(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testLintRules),
+		"test.rego": mustParseModule("test.rego", `package test
+
+bar = true
+foo = true`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{
+		{
+			File:    "test.rego",
+			Row:     4,
+			Col:     1,
+			Message: "rules must not be named foo",
+			RuleID:  "system.lint.deny",
+		},
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues %v but got: %v", expected, report.Issues)
+	}
+
+	if report.Termination.Reason != TerminationCompleted {
+		t.Fatalf("Expected completed termination but got: %+v", report.Termination)
+	}
+}
+
+func TestRunnerLintUndefined(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"test.rego": ast.MustParseModule(`package test
+
+foo = true`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	if len(report.Issues) != 0 {
+		t.Fatalf("Expected no issues but got: %v", report.Issues)
+	}
+}
+
+func TestRunnerLintStore(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny[{"message": "not allowed", "location": {"file": file}}] {
+	input.modules[file]
+	not data.allowed[file]
+}`),
+		"a.rego": ast.MustParseModule(`package a`),
+		"b.rego": ast.MustParseModule(`package b`),
+	}
+
+	data := map[string]interface{}{
+		"allowed": map[string]interface{}{
+			"lint.rego": true,
+			"a.rego":    true,
+		},
+	}
+
+	ctx := context.Background()
+	store := storage.New(storage.InMemoryWithJSONConfig(data))
+
+	if err := store.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Close(ctx, txn)
+
+	runner := New().SetModules(modules).SetStore(store)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, txn)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{{File: "b.rego", Message: "not allowed", RuleID: "system.lint.deny"}}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues %v but got: %v", expected, report.Issues)
+	}
+}
+
+func TestRunnerLintEvalError(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+name = n { input.modules[_].rules[_].head.name = n }
+
+deny[{"message": name}] { true }`),
+		"test.rego": ast.MustParseModule(`package test
+
+foo = true
+bar = true`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if !topdown.IsError(err) {
+		t.Fatalf("Expected evaluation error but got: %v (report: %v)", err, report)
+	}
+}
+
+func TestRunnerLintNotCompiled(t *testing.T) {
+	if _, err := New().Lint(context.Background(), nil); err == nil {
+		t.Fatal("Expected error when linting before compile")
+	}
+}
+
+func mustParseModule(filename, input string) *ast.Module {
+	module, err := ast.ParseModule(filename, input)
+	if err != nil {
+		panic(err)
+	}
+	return module
+}
+
+func TestRunnerPrintParsed(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", `package a
+
+p = true`),
+		"b_test.rego": mustParseModule("b_test.rego", `package b`),
+	}
+
+	ctx := context.Background()
+	buf := new(bytes.Buffer)
+	runner := New().SetModules(modules).SetOutput(buf).SetIgnore([]string{"*_test.rego"})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	if err := runner.PrintParsed(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Modules map[string]struct {
+			Rules []struct {
+				Location struct {
+					File string `json:"file"`
+					Row  int    `json:"row"`
+				} `json:"location"`
+			} `json:"rules"`
+		} `json:"modules"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Unexpected error decoding output %q: %v", buf.String(), err)
+	}
+
+	if len(result.Modules) != 1 || len(result.Modules["a.rego"].Rules) != 1 {
+		t.Fatalf("Expected only a.rego in output but got: %v", buf.String())
+	}
+
+	if loc := result.Modules["a.rego"].Rules[0].Location; loc.File != "a.rego" || loc.Row != 3 {
+		t.Fatalf("Expected rule location a.rego:3 but got: %+v", loc)
+	}
+}
+
+func TestRunnerLintNoOutput(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testLintRules),
+		"test.rego": mustParseModule("test.rego", `package test
+
+foo = true`),
+	}
+
+	ctx := context.Background()
+	buf := new(bytes.Buffer)
+	runner := New().SetModules(modules).SetOutput(buf)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	if _, err := runner.Lint(ctx, nil); err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected Lint to produce no output but got: %v", buf.String())
+	}
+}
+
+// slowLintModules returns lint rules that take much longer than the timeouts
+// used in tests to evaluate.
+func slowLintModules() map[string]*ast.Module {
+
+	nums := make([]string, 100)
+	for i := range nums {
+		nums[i] = fmt.Sprint(i)
+	}
+
+	return map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(fmt.Sprintf(`package system.lint
+
+nums = [%v]
+
+deny[{"message": "slow"}] {
+	nums[a]
+	nums[b]
+	nums[c]
+	nums[d]
+	d = -1
+}`, strings.Join(nums, ","))),
+	}
+}
+
+func TestRunnerLintTimeout(t *testing.T) {
+
+	ctx := context.Background()
+	runner := New().SetModules(slowLintModules()).SetTimeout(10 * time.Millisecond)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	t0 := time.Now()
+	report, err := runner.Lint(ctx, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "lint evaluation timed out after 10ms") {
+		t.Fatalf("Expected timeout error but got: %v", err)
+	}
+
+	if d := time.Since(t0); d > time.Second {
+		t.Fatalf("Expected evaluation to stop shortly after timeout but took %v", d)
+	}
+
+	expected := Termination{
+		Reason:      TerminationTimeout,
+		Limit:       "10ms",
+		Unevaluated: 1,
+	}
+
+	if report == nil || !reflect.DeepEqual(report.Termination, expected) {
+		t.Fatalf("Expected partial report with termination %+v but got: %+v", expected, report)
+	}
+}
+
+func TestRunnerLintCallerDeadline(t *testing.T) {
+
+	runner := New().SetModules(slowLintModules()).SetTimeout(time.Minute)
+
+	if err := runner.Compile(context.Background()); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	report, err := runner.Lint(ctx, nil)
+
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected caller's context error but got: %v", err)
+	}
+
+	expected := Termination{
+		Reason:      TerminationInterrupted,
+		Unevaluated: 1,
+	}
+
+	if report == nil || !reflect.DeepEqual(report.Termination, expected) {
+		t.Fatalf("Expected partial report with termination %+v but got: %+v", expected, report)
+	}
+}
+
+func TestRunnerLintInterrupted(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testLintRules),
+		"test.rego": mustParseModule("test.rego", `package test
+
+foo = true`),
+	}
+
+	runner := New().SetModules(modules)
+
+	if err := runner.Compile(context.Background()); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := runner.Lint(ctx, nil)
+
+	if err != context.Canceled {
+		t.Fatalf("Expected context error but got: %v", err)
+	}
+
+	expected := Termination{
+		Reason:      TerminationInterrupted,
+		Unevaluated: 2,
+	}
+
+	if report == nil || !reflect.DeepEqual(report.Termination, expected) {
+		t.Fatalf("Expected partial report with termination %+v but got: %+v", expected, report)
+	}
+}
+
+func TestRunnerLintFilter(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint/rules.rego": ast.MustParseModule(`package system.lint
+
+deny[{"message": "module", "location": {"file": file}}] {
+	input.modules[file]
+}`),
+		"authz/a.rego":          ast.MustParseModule(`package authz.a`),
+		"policies/authz/b.rego": ast.MustParseModule(`package authz.b`),
+		"policies/c.rego":       ast.MustParseModule(`package c`),
+	}
+
+	tests := []struct {
+		note     string
+		filter   string
+		expected []string
+	}{
+		{"unanchored", "authz/.*", []string{"authz/a.rego", "policies/authz/b.rego"}},
+		{"anchored", "^authz/", []string{"authz/a.rego"}},
+		{"no match", "^nothing$", nil},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).Filter(tc.filter)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		var files []string
+
+		for _, issue := range report.Issues {
+			files = append(files, issue.File)
+		}
+
+		sort.Strings(files)
+
+		if !reflect.DeepEqual(files, tc.expected) {
+			t.Errorf("%v: Expected issues for %v but got: %v", tc.note, tc.expected, report.Issues)
+		}
+	}
+}
+
+func TestRunnerCompileBadFilter(t *testing.T) {
+	runner := New().Filter("authz/(")
+	err := runner.Compile(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid filter") {
+		t.Fatalf("Expected invalid filter error but got: %v", err)
+	}
+}
+
+func TestRunnerCompileBadFailOn(t *testing.T) {
+	runner := New().SetFailOn("fatal")
+	err := runner.Compile(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid fail-on severity") {
+		t.Fatalf("Expected invalid fail-on severity error but got: %v", err)
+	}
+}
+
+func TestRunnerLintMaxIssues(t *testing.T) {
+
+	modules := map[string]*ast.Module{}
+
+	for file, src := range testFormatSources {
+		modules[file] = mustParseModule(file, string(src))
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testFormatRules),
+	}
+
+	tests := []struct {
+		max       int
+		expected  []string
+		truncated bool
+	}{
+		{0, []string{":0", "policies/a.rego:3", "policies/b.rego:4", "policies/b.rego:5"}, false},
+		{4, []string{":0", "policies/a.rego:3", "policies/b.rego:4", "policies/b.rego:5"}, false},
+		{2, []string{"policies/a.rego:3", "policies/b.rego:4"}, true},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules).SetMaxIssues(tc.max)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("Unexpected lint error: %v", err)
+		}
+
+		result := []string{}
+		for _, issue := range report.Issues {
+			result = append(result, fmt.Sprintf("%v:%d", issue.File, issue.Row))
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%d: expected issues %v but got %v", tc.max, tc.expected, result)
+		}
+
+		if report.Truncated != tc.truncated || (tc.truncated && report.TotalIssues != 4) {
+			t.Errorf("%d: expected truncated %v but got %v (total: %d)", tc.max, tc.truncated, report.Truncated, report.TotalIssues)
+		}
+
+		if report.Summary.Errors != 3 || report.Summary.Warnings != 1 {
+			t.Errorf("%d: expected summary to count all issues but got %+v", tc.max, report.Summary)
+		}
+	}
+
+	if err := New().SetMaxIssues(-1).Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for negative maximum")
+	}
+}
+
+const testEntrypointRules = `package system.lint
+
+deny[{"message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+warn[{"message": "rules should not be named bar", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "bar"
+}
+
+warn[{"message": "rules named baz are deprecated", "severity": "info", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "baz"
+}
+
+info[{"message": "module has rules", "location": module["package"].location}] {
+	module = input.modules[_]
+	module.rules[_]
+}`
+
+func TestRunnerLintEntrypoints(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nfoo = true\nbar = true\nbaz = true"),
+	}
+
+	tests := []struct {
+		note     string
+		rules    string
+		query    string
+		expected []string
+	}{
+		{
+			note:  "all entrypoints",
+			rules: testEntrypointRules,
+			expected: []string{
+				"1 info module has rules",
+				"2  rules must not be named foo",
+				"3 warn rules should not be named bar",
+				"4 info rules named baz are deprecated",
+			},
+		},
+		{
+			note:     "missing entrypoints",
+			rules:    testFormatRules,
+			expected: []string{"0 warn policy set is too small", "2  rules must not be named foo"},
+		},
+		{
+			note:     "other query",
+			rules:    testEntrypointRules,
+			query:    "data.system.lint.warn",
+			expected: []string{"3  rules should not be named bar", "4 info rules named baz are deprecated"},
+		},
+	}
+
+	for _, tc := range tests {
+
+		rules := map[string]*ast.Module{
+			"lint.rego": ast.MustParseModule(tc.rules),
+		}
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules)
+
+		if tc.query != "" {
+			runner.SetQuery(tc.query)
+		}
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		result := []string{}
+		for _, issue := range report.Issues {
+			result = append(result, fmt.Sprintf("%d %v %v", issue.Row, issue.Severity, issue.Message))
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected issues %v but got %v", tc.note, tc.expected, result)
+		}
+	}
+}
+
+func TestRunnerLintQueries(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nfoo = true\nbar = true"),
+	}
+
+	rules := map[string]*ast.Module{
+		"org.rego": mustParseModule("org.rego", `package system.lint
+
+deny[{"message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}`),
+		"team.rego": mustParseModule("team.rego", `package teams.payments.lint
+
+deny[{"message": "rules must not be named foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+warn[{"message": "rules should not be named bar", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "bar"
+}`),
+		"broken.rego": mustParseModule("broken.rego", `package broken
+
+deny = true`),
+	}
+
+	tests := []struct {
+		note     string
+		queries  []string
+		expected []string
+		failed   []string
+	}{
+		{
+			note:    "merged",
+			queries: []string{DefaultQuery, "data.teams.payments.lint.deny"},
+			expected: []string{
+				"2 data.system.lint.deny rules must not be named foo",
+				"3 data.teams.payments.lint.deny rules should not be named bar",
+			},
+		},
+		{
+			note:    "failure",
+			queries: []string{"data.broken.deny", "data.teams.payments.lint.deny"},
+			expected: []string{
+				"2 data.teams.payments.lint.deny rules must not be named foo",
+				"3 data.teams.payments.lint.deny rules should not be named bar",
+			},
+			failed: []string{"data.broken.deny"},
+		},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules).SetQueries(tc.queries)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		if !reflect.DeepEqual(runner.Queries(), tc.queries) {
+			t.Errorf("%v: expected queries %v but got %v", tc.note, tc.queries, runner.Queries())
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if (err != nil) != (len(tc.failed) > 0) || report == nil {
+			t.Fatalf("%v: Unexpected lint result: %v (err: %v)", tc.note, report, err)
+		}
+
+		result := []string{}
+		for _, issue := range report.Issues {
+			result = append(result, fmt.Sprintf("%d %v %v", issue.Row, issue.Query, issue.Message))
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%v: expected issues %v but got %v", tc.note, tc.expected, result)
+		}
+
+		failed := []string{}
+		for _, qerr := range report.QueryErrors {
+			failed = append(failed, qerr.Query)
+		}
+
+		if len(failed) > 0 || len(tc.failed) > 0 {
+			if !reflect.DeepEqual(failed, tc.failed) {
+				t.Errorf("%v: expected failed queries %v but got %v", tc.note, tc.failed, report.QueryErrors)
+			}
+		}
+	}
+}
+
+func TestRunnerLintSeverities(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nfoo = true\nbar = true\nbaz = true\nqux = true"),
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": mustParseModule("lint.rego", `package system.lint
+
+deny[{"message": rule.head.name, "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+deny[{"message": rule.head.name, "severity": "warning", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "bar"
+}
+
+warn[{"message": rule.head.name, "severity": "error", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "baz"
+}
+
+info[{"message": rule.head.name, "severity": "critical", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "qux"
+}
+
+warn[{"message": "module", "location": module["package"].location}] {
+	module = input.modules[_]
+}`),
+	}
+
+	tests := []struct {
+		failOn   string
+		failures int
+	}{
+		{SeverityError, 3},
+		{SeverityWarn, 5},
+		{SeverityInfo, 5},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules).SetFailOn(tc.failOn)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("Unexpected lint error: %v", err)
+		}
+
+		result := []string{}
+		for _, issue := range report.Issues {
+			result = append(result, issue.Message+":"+issue.Level())
+		}
+
+		expected := []string{"module:warn", "foo:error", "bar:warn", "baz:error", "qux:error"}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("Expected issues %v but got %v", expected, result)
+		}
+
+		if report.Summary.Errors != 3 || report.Summary.Warnings != 2 || report.Summary.Failures != tc.failures {
+			t.Errorf("%v: unexpected summary: %+v", tc.failOn, report.Summary)
+		}
+
+		diags := []string{`unknown severity "critical" normalized to error (1 issue)`}
+
+		if !reflect.DeepEqual(report.Diagnostics, diags) {
+			t.Errorf("Expected diagnostics %v but got %v", diags, report.Diagnostics)
+		}
+	}
+}
+
+func TestRunnerLintFailureLine(t *testing.T) {
+
+	src := `package test
+
+bar = true
+foo = true`
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testLintRules + `
+
+deny[{"message": "past end", "location": {"file": "test.rego", "row": 10}}] { true }`),
+		"test.rego": mustParseModule("test.rego", src),
+	}
+
+	sources := map[string][]byte{
+		"test.rego": []byte(src),
+	}
+
+	for _, enabled := range []bool{false, true} {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetSources(sources).EnableFailureLine(enabled)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("Unexpected lint error: %v", err)
+		}
+
+		lines := map[int]string{}
+
+		for _, issue := range report.Issues {
+			lines[issue.Row] = issue.Line
+		}
+
+		expected := map[int]string{4: "", 10: ""}
+
+		if enabled {
+			expected[4] = "foo = true"
+		}
+
+		if !reflect.DeepEqual(lines, expected) {
+			t.Errorf("Expected lines %v (enabled: %v) but got: %v", expected, enabled, lines)
+		}
+	}
+}
+
+func TestRunnerLintModules(t *testing.T) {
+
+	rules := map[string]*ast.Module{
+		"lint.rego": mustParseModule("lint.rego", testLintRules+`
+
+foo = true
+
+deny[{"message": "module", "location": {"file": file}}] {
+	input.modules[file]
+}`),
+	}
+
+	modules := map[string]*ast.Module{
+		"test.rego": mustParseModule("test.rego", `package test
+
+foo = true`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetLintModules(rules)
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	expected := []Issue{
+		{File: "test.rego", Message: "module", RuleID: "system.lint.deny"},
+		{File: "test.rego", Row: 3, Col: 1, Message: "rules must not be named foo", RuleID: "system.lint.deny"},
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Message < report.Issues[j].Message
+	})
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Fatalf("Expected issues %v but got: %v", expected, report.Issues)
+	}
+}
+
+func TestRunnerCompileLintModuleConflict(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": ast.MustParseModule(`package a`),
+	}
+
+	runner := New().SetModules(modules).SetLintModules(modules)
+
+	if err := runner.Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for module that is both a lint module and a module to lint")
+	}
+}
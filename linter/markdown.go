@@ -0,0 +1,155 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Default limits of Markdown reports.
+const (
+	DefaultMarkdownDetailsThreshold = 10
+	DefaultMarkdownMaxSize          = 65000
+)
+
+// MarkdownOptions controls the layout of Markdown reports.
+type MarkdownOptions struct {
+
+	// DetailsThreshold is the number of issues above which the issues of
+	// each file are rendered in a collapsible <details> section. If zero,
+	// DefaultMarkdownDetailsThreshold is used.
+	DetailsThreshold int
+
+	// MaxSize is the maximum size of the report in bytes. Issues that do not
+	// fit are omitted and a note is added to the report. If zero,
+	// DefaultMarkdownMaxSize is used.
+	MaxSize int
+}
+
+// markdownTruncationReserve is the space kept free for the truncation note.
+const markdownTruncationReserve = 128
+
+// printMarkdown prints report as Markdown that is suitable for pull request
+// comments.
+func (r *Runner) printMarkdown(report *Report) error {
+
+	threshold := r.markdown.DetailsThreshold
+	if threshold <= 0 {
+		threshold = DefaultMarkdownDetailsThreshold
+	}
+
+	maxSize := r.markdown.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMarkdownMaxSize
+	}
+
+	issues := sortedIssues(report.Issues)
+	files := 0
+
+	for i := range issues {
+		if i == 0 || issues[i].File != issues[i-1].File {
+			files++
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "**%v across %v**\n", plural(len(issues), "violation"), plural(files, "file"))
+
+	if len(issues) == 0 {
+		_, err := r.output.Write(buf.Bytes())
+		return err
+	}
+
+	details := len(issues) > threshold
+	footer := ""
+	shown := 0
+
+	if !details {
+		buf.WriteString("\n| File | Line | Rule | Message |\n| --- | --- | --- | --- |\n")
+	}
+
+	for i, issue := range issues {
+
+		var chunk string
+
+		if details && (i == 0 || issue.File != issues[i-1].File) {
+			count := 1
+			for j := i + 1; j < len(issues) && issues[j].File == issue.File; j++ {
+				count++
+			}
+			chunk = footer + fmt.Sprintf("\n<details>\n<summary><code>%v</code> (%v)</summary>\n\n| Line | Rule | Message |\n| --- | --- | --- |\n",
+				html.EscapeString(markdownFileName(issue.File)), plural(count, "violation"))
+			footer = "\n</details>\n"
+		}
+
+		if details {
+			chunk += fmt.Sprintf("| %v | %v | %v |\n", markdownLine(issue), markdownCode(issue.RuleID), markdownText(issue.Message))
+		} else {
+			chunk += fmt.Sprintf("| %v | %v | %v | %v |\n", markdownCode(markdownFileName(issue.File)), markdownLine(issue), markdownCode(issue.RuleID), markdownText(issue.Message))
+		}
+
+		if buf.Len()+len(chunk)+len(footer)+markdownTruncationReserve > maxSize {
+			break
+		}
+
+		buf.WriteString(chunk)
+		shown++
+	}
+
+	buf.WriteString(footer)
+
+	if shown < len(issues) {
+		fmt.Fprintf(&buf, "\n_Output truncated: %v of %v violations shown._\n", shown, len(issues))
+	}
+
+	_, err := r.output.Write(buf.Bytes())
+	return err
+}
+
+func markdownFileName(file string) string {
+	if file == "" {
+		return "(no file)"
+	}
+	return file
+}
+
+func markdownLine(issue Issue) string {
+	if issue.Row <= 0 {
+		return ""
+	}
+	return fmt.Sprint(issue.Row)
+}
+
+// markdownCode returns s as a code span. Pipes are escaped so that the span
+// can be used in a table.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.Replace(s, "|", "\\|", -1)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+// markdownText returns s escaped for use in a table cell.
+func markdownText(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	s = strings.Replace(s, "\r\n", "<br>", -1)
+	return strings.Replace(s, "\n", "<br>", -1)
+}
+
+// plural returns the count n of noun, e.g., "1 file" or "2 files".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
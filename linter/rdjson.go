@@ -0,0 +1,98 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity"`
+	Code     *rdjsonCode    `json:"code,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string       `json:"path"`
+	Range *rdjsonRange `json:"range,omitempty"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition  `json:"start"`
+	End   *rdjsonPosition `json:"end,omitempty"`
+}
+
+type rdjsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+// printRDJSON prints report in the reviewdog diagnostic format (rdjson). The
+// range of a diagnostic only includes an end position if the issue has one.
+func (r *Runner) printRDJSON(report *Report) error {
+
+	result := rdjsonResult{
+		Source:      rdjsonSource{Name: "opa-lint"},
+		Diagnostics: []rdjsonDiagnostic{},
+	}
+
+	for _, issue := range sortedIssues(report.Issues) {
+
+		diagnostic := rdjsonDiagnostic{
+			Message:  issue.Message,
+			Location: rdjsonLocation{Path: issue.File},
+			Severity: rdjsonSeverity(issue.Level()),
+		}
+
+		if issue.Row > 0 {
+			diagnostic.Location.Range = &rdjsonRange{
+				Start: rdjsonPosition{Line: issue.Row, Column: issue.Col},
+			}
+			if issue.EndRow > 0 {
+				diagnostic.Location.Range.End = &rdjsonPosition{Line: issue.EndRow, Column: issue.EndCol}
+			}
+		}
+
+		if issue.RuleID != "" {
+			diagnostic.Code = &rdjsonCode{Value: issue.RuleID}
+		}
+
+		result.Diagnostics = append(result.Diagnostics, diagnostic)
+	}
+
+	bs, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(r.output, string(bs))
+	return err
+}
+
+// rdjsonSeverity returns the rdjson severity of an issue with level.
+func rdjsonSeverity(level string) string {
+	switch level {
+	case SeverityWarn:
+		return "WARNING"
+	case SeverityInfo:
+		return "INFO"
+	}
+	return "ERROR"
+}
@@ -0,0 +1,146 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// rulePatterns is a set of glob patterns matched against rule identifiers.
+// Patterns use the syntax of path.Match, e.g., "style/*" matches
+// "style/line-length" but not "style/naming/case".
+type rulePatterns []string
+
+func (ps rulePatterns) validate() error {
+	for _, p := range ps {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("bad rule pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+func (ps rulePatterns) Match(id string) bool {
+	for _, p := range ps {
+		if ok, _ := path.Match(p, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleEnabled returns true if the issue is reported. If enabled rules are
+// set, only the issues of matching rules are reported. The issues of rules
+// disabled with SetDisabledRules or in the settings of the issue's file (see
+// SetLintConfigs) are never reported.
+func (r *Runner) ruleEnabled(issue Issue) bool {
+
+	if len(r.enabledRules) > 0 && !r.enabledRules.Match(issue.RuleID) {
+		return false
+	}
+
+	if r.disabledRules.Match(issue.RuleID) {
+		return false
+	}
+
+	for _, dir := range r.lintConfigDirs(issue.File) {
+		if rulePatterns(r.lintConfigs[dir].Disable).Match(issue.RuleID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterRules returns the issues whose rules are enabled and the number of
+// issues that were removed.
+func (r *Runner) filterRules(issues []Issue) ([]Issue, int) {
+
+	kept := make([]Issue, 0, len(issues))
+
+	for _, issue := range issues {
+		if r.ruleEnabled(issue) {
+			kept = append(kept, issue)
+		}
+	}
+
+	return kept, len(issues) - len(kept)
+}
+
+// severityOverride identifies a severity override set for the rule id with
+// SetSeverities (dir is empty and global is true) or in the settings of dir.
+type severityOverride struct {
+	global bool
+	dir    string
+	id     string
+}
+
+// overrideSeverities returns issues with the severities set with
+// SetSeverities and SetLintConfigs applied and a note for each override that
+// did not match any issue.
+func (r *Runner) overrideSeverities(issues []Issue) ([]Issue, []string) {
+
+	used := map[severityOverride]struct{}{}
+
+	for i := range issues {
+		if override, level, ok := r.severityFor(issues[i]); ok {
+			issues[i].Severity = level
+			used[override] = struct{}{}
+		}
+	}
+
+	var notes []string
+
+	for id := range r.severities {
+		if _, ok := used[severityOverride{global: true, id: id}]; !ok {
+			notes = append(notes, fmt.Sprintf("severity override for rule %v did not match any issues", id))
+		}
+	}
+
+	for dir, config := range r.lintConfigs {
+		if config == nil {
+			continue
+		}
+		for id := range config.Severity {
+			if _, ok := used[severityOverride{dir: dir, id: id}]; !ok {
+				notes = append(notes, fmt.Sprintf("severity override for rule %v in settings of %v did not match any issues", id, displayDir(dir)))
+			}
+		}
+	}
+
+	sort.Strings(notes)
+
+	return issues, notes
+}
+
+// severityFor returns the severity override that applies to issue. Overrides
+// set with SetSeverities take precedence over the settings of the deepest
+// directory that contains the issue's file.
+func (r *Runner) severityFor(issue Issue) (severityOverride, string, bool) {
+
+	if level, ok := r.severities[issue.RuleID]; ok {
+		return severityOverride{global: true, id: issue.RuleID}, level, true
+	}
+
+	dirs := r.lintConfigDirs(issue.File)
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if level, ok := r.lintConfigs[dirs[i]].Severity[issue.RuleID]; ok {
+			return severityOverride{dir: dirs[i], id: issue.RuleID}, level, true
+		}
+	}
+
+	return severityOverride{}, "", false
+}
+
+// displayDir returns the name of dir used in messages.
+func displayDir(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
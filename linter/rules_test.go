@@ -0,0 +1,195 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestRulePatternsMatch(t *testing.T) {
+
+	tests := []struct {
+		pattern string
+		id      string
+		match   bool
+	}{
+		{"style/*", "style/line-length", true},
+		{"style/*", "style/naming/case", false},
+		{"style/*", "security/plaintext", false},
+		{"*", "system.lint.deny", true},
+		{"system.lint.*", "system.lint.warn", true},
+		{"no-foo", "no-foo", true},
+		{"no-foo", "no-foo-bar", false},
+	}
+
+	for _, tc := range tests {
+		if result := rulePatterns([]string{tc.pattern}).Match(tc.id); result != tc.match {
+			t.Errorf("Expected %q match %q to be %v", tc.pattern, tc.id, tc.match)
+		}
+	}
+}
+
+func TestRunnerLintEnabledRules(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint/rules.rego": ast.MustParseModule(`package system.lint
+
+deny[{"id": "style/naming", "message": "naming"}] { true }
+deny[{"id": "style/length", "message": "length"}] { true }
+deny[{"id": "security/plaintext", "message": "plaintext"}] { true }
+deny["no id"] { true }
+warn["warning"] { true }`),
+	}
+
+	tests := []struct {
+		note     string
+		enabled  []string
+		disabled []string
+		expected []string
+		dropped  int
+	}{
+		{
+			note:     "all",
+			expected: []string{"security/plaintext", "style/length", "style/naming", "system.lint.deny", "system.lint.warn"},
+		},
+		{
+			note:     "disabled",
+			disabled: []string{"style/*", "system.lint.warn"},
+			expected: []string{"security/plaintext", "system.lint.deny"},
+			dropped:  3,
+		},
+		{
+			note:     "enabled",
+			enabled:  []string{"style/*"},
+			expected: []string{"style/length", "style/naming"},
+			dropped:  3,
+		},
+		{
+			note:     "enabled and disabled",
+			enabled:  []string{"style/*", "security/*"},
+			disabled: []string{"style/length"},
+			expected: []string{"security/plaintext", "style/naming"},
+			dropped:  3,
+		},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetEnabledRules(tc.enabled).SetDisabledRules(tc.disabled)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("%v: Unexpected compile error: %v", tc.note, err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: Unexpected lint error: %v", tc.note, err)
+		}
+
+		ids := []string{}
+
+		for _, issue := range report.Issues {
+			ids = append(ids, issue.RuleID)
+		}
+
+		sort.Strings(ids)
+
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("%v: Expected issues of %v but got: %v", tc.note, tc.expected, report.Issues)
+		}
+
+		if report.Summary.Disabled != tc.dropped {
+			t.Errorf("%v: Expected %d disabled issues but got summary: %+v", tc.note, tc.dropped, report.Summary)
+		}
+	}
+}
+
+func TestRunnerCompileBadRulePattern(t *testing.T) {
+	if err := New().SetEnabledRules([]string{"[a-"}).Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for bad enabled rule pattern")
+	}
+	if err := New().SetDisabledRules([]string{"[a-"}).Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for bad disabled rule pattern")
+	}
+}
+
+func TestRunnerLintSeverityOverrides(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint/rules.rego": ast.MustParseModule(`package system.lint
+
+deny[{"id": "no-todo-comments", "message": "todo"}] { true }
+deny[{"id": "unsafe-builtin", "message": "unsafe", "severity": "warning"}] { true }
+warn[{"id": "naming", "message": "naming"}] { true }`),
+	}
+
+	overrides := map[string]string{
+		"no-todo-comments": SeverityWarn,
+		"unsafe-builtin":   SeverityError,
+		"typo":             SeverityInfo,
+	}
+
+	tests := []struct {
+		failOn   string
+		failures int
+	}{
+		{SeverityError, 1},
+		{SeverityWarn, 3},
+		{SeverityInfo, 3},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetSeverities(overrides).SetFailOn(tc.failOn)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("Unexpected lint error: %v", err)
+		}
+
+		severities := map[string]string{}
+
+		for _, issue := range report.Issues {
+			severities[issue.RuleID] = issue.Level()
+		}
+
+		expected := map[string]string{
+			"no-todo-comments": SeverityWarn,
+			"unsafe-builtin":   SeverityError,
+			"naming":           SeverityWarn,
+		}
+
+		if !reflect.DeepEqual(severities, expected) {
+			t.Fatalf("Expected severities %v but got: %v", expected, severities)
+		}
+
+		if report.Summary.Failures != tc.failures {
+			t.Errorf("Expected %d failures with fail-on %v but got: %+v", tc.failures, tc.failOn, report.Summary)
+		}
+
+		notes := []string{"severity override for rule typo did not match any issues"}
+
+		if !reflect.DeepEqual(report.Notes, notes) {
+			t.Errorf("Expected notes %v but got: %v", notes, report.Notes)
+		}
+	}
+}
+
+func TestRunnerCompileBadSeverity(t *testing.T) {
+	if err := New().SetSeverities(map[string]string{"no-foo": "critical"}).Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for bad severity override")
+	}
+}
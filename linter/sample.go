@@ -0,0 +1,86 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Sample configures the Runner to lint a deterministic subset of the modules.
+// Modules are ranked by a hash of the seed and the module's file name so that
+// runs with the same seed select the same modules. Either Fraction or Count
+// must be set.
+type Sample struct {
+	Fraction float64 // Fraction of modules to lint, in the range (0, 1].
+	Count    int     // Number of modules to lint.
+	Seed     string  // Seed used to rank modules.
+}
+
+// SampleReport describes the sample that a lint run was restricted to. If a
+// report contains a SampleReport, the issues only cover the sampled modules.
+type SampleReport struct {
+	Fraction float64 `json:"fraction,omitempty"`
+	Count    int     `json:"count,omitempty"`
+	Seed     string  `json:"seed"`
+	Sampled  int     `json:"sampled"`
+	Total    int     `json:"total"`
+}
+
+func (s *Sample) validate() error {
+	if s.Fraction != 0 && s.Count != 0 {
+		return fmt.Errorf("sample fraction and count cannot both be set")
+	}
+	if s.Fraction < 0 || s.Fraction > 1 {
+		return fmt.Errorf("sample fraction must be in the range (0, 1] but got %v", s.Fraction)
+	}
+	if s.Count < 0 {
+		return fmt.Errorf("sample count must be positive but got %v", s.Count)
+	}
+	if s.Fraction == 0 && s.Count == 0 {
+		return fmt.Errorf("sample fraction or count must be set")
+	}
+	return nil
+}
+
+// ids returns the subset of ids selected by the sample.
+func (s *Sample) ids(ids []string) []string {
+
+	n := s.Count
+
+	if s.Fraction != 0 {
+		n = int(math.Ceil(s.Fraction * float64(len(ids))))
+	}
+
+	if n >= len(ids) {
+		return ids
+	}
+
+	ranks := make(map[string]uint64, len(ids))
+
+	for _, id := range ids {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%v\x00%v", s.Seed, id)
+		ranks[id] = h.Sum64()
+	}
+
+	sorted := make([]string, len(ids))
+	copy(sorted, ids)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := ranks[sorted[i]], ranks[sorted[j]]
+		if a != b {
+			return a < b
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	result := sorted[:n]
+	sort.Strings(result)
+
+	return result
+}
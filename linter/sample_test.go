@@ -0,0 +1,111 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestSampleIDs(t *testing.T) {
+
+	var ids []string
+
+	for i := 0; i < 100; i++ {
+		ids = append(ids, fmt.Sprintf("%02d.rego", i))
+	}
+
+	a := (&Sample{Fraction: 0.1, Seed: "2017-06-01"}).ids(ids)
+	b := (&Sample{Fraction: 0.1, Seed: "2017-06-01"}).ids(ids)
+	c := (&Sample{Fraction: 0.1, Seed: "2017-06-02"}).ids(ids)
+
+	if len(a) != 10 {
+		t.Fatalf("Expected 10 ids but got: %v", a)
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("Expected same seed to select same ids but got %v and %v", a, b)
+	}
+
+	if reflect.DeepEqual(a, c) {
+		t.Fatalf("Expected different seeds to select different ids but got %v", a)
+	}
+
+	if d := (&Sample{Count: 3, Seed: "x"}).ids(ids); len(d) != 3 {
+		t.Fatalf("Expected 3 ids but got: %v", d)
+	}
+
+	if e := (&Sample{Count: 1000, Seed: "x"}).ids(ids); len(e) != len(ids) {
+		t.Fatalf("Expected all ids but got: %v", e)
+	}
+}
+
+func TestSampleValidate(t *testing.T) {
+
+	tests := []struct {
+		sample Sample
+		valid  bool
+	}{
+		{Sample{Fraction: 0.5}, true},
+		{Sample{Count: 10}, true},
+		{Sample{}, false},
+		{Sample{Fraction: 1.5}, false},
+		{Sample{Fraction: -0.5}, false},
+		{Sample{Count: -1}, false},
+		{Sample{Fraction: 0.5, Count: 10}, false},
+	}
+
+	for _, tc := range tests {
+		if err := tc.sample.validate(); (err == nil) != tc.valid {
+			t.Errorf("Expected %+v valid=%v but got err: %v", tc.sample, tc.valid, err)
+		}
+	}
+}
+
+func TestRunnerLintSample(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny[{"message": "module", "location": {"file": file}}] { input.modules[file] }`),
+	}
+
+	for i := 0; i < 9; i++ {
+		modules[fmt.Sprintf("%v.rego", i)] = ast.MustParseModule(fmt.Sprintf("package p%v", i))
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetSample(&Sample{Count: 4, Seed: "test"})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	if len(report.Issues) != 4 {
+		t.Fatalf("Expected 4 issues but got: %v", report.Issues)
+	}
+
+	expected := &SampleReport{Count: 4, Seed: "test", Sampled: 4, Total: 10}
+
+	if !reflect.DeepEqual(report.Sample, expected) {
+		t.Fatalf("Expected sample report %+v but got: %+v", expected, report.Sample)
+	}
+}
+
+func TestRunnerCompileBadSample(t *testing.T) {
+	runner := New().SetSample(&Sample{Fraction: 2})
+	if err := runner.Compile(context.Background()); err == nil {
+		t.Fatal("Expected error for invalid sample")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// summarize returns the Summary of issues found in a run that scanned n files
+// and took d. Issues at or above the failOn level are counted as failures.
+func summarize(issues []Issue, n int, failOn string, d time.Duration) Summary {
+
+	summary := Summary{
+		FilesScanned: n,
+		DurationMS:   int64(d / time.Millisecond),
+	}
+
+	files := map[string]struct{}{}
+
+	for _, issue := range issues {
+		switch issue.Level() {
+		case SeverityError:
+			summary.Errors++
+		case SeverityWarn:
+			summary.Warnings++
+		case SeverityInfo:
+			summary.Infos++
+		}
+		if atLeast(issue.Level(), failOn) {
+			summary.Failures++
+		}
+		if issue.File != "" {
+			files[issue.File] = struct{}{}
+		}
+	}
+
+	summary.FilesWithIssues = len(files)
+
+	return summary
+}
+
+// exitReason returns the explanation of whether the run that produced report
+// failed.
+func exitReason(report *Report, failOn string) string {
+
+	switch report.Termination.Reason {
+	case TerminationTimeout:
+		return "lint evaluation timed out after " + report.Termination.Limit
+	case TerminationInterrupted:
+		return "lint evaluation interrupted"
+	}
+
+	switch n := len(report.QueryErrors); {
+	case n == 1:
+		return "lint query " + report.QueryErrors[0].Query + " failed"
+	case n > 1:
+		return fmt.Sprintf("%d lint queries failed", n)
+	}
+
+	if report.Summary.Failures == 0 {
+		return fmt.Sprintf("no issues with severity %v or higher", failOn)
+	}
+
+	return fmt.Sprintf("%v with severity %v or higher", plural(report.Summary.Failures, "issue"), failOn)
+}
+
+// PrintSummary prints a single line summarizing the Summary of report to the
+// Runner's output, e.g., "Scanned 148 files: 3 errors, 11 warnings in 7 files
+// (1.2s)". Issues of disabled rules and suppressed issues are counted
+// separately. If the report was truncated, a notice is printed first.
+func (r *Runner) PrintSummary(report *Report) error {
+
+	if report.Truncated {
+		if _, err := fmt.Fprintf(r.output, "output truncated at %v (%v found)\n", plural(len(report.Issues), "issue"), report.TotalIssues); err != nil {
+			return err
+		}
+	}
+
+	s := report.Summary
+	line := fmt.Sprintf("Scanned %v: ", plural(s.FilesScanned, "file"))
+
+	if s.Errors == 0 && s.Warnings == 0 && s.Infos == 0 {
+		line += "no issues found"
+	} else {
+		line += fmt.Sprintf("%v, %v", plural(s.Errors, "error"), plural(s.Warnings, "warning"))
+		if s.Infos > 0 {
+			line += ", " + plural(s.Infos, "info")
+		}
+		if s.FilesWithIssues > 0 {
+			line += " in " + plural(s.FilesWithIssues, "file")
+		}
+	}
+
+	if s.Disabled > 0 {
+		line += fmt.Sprintf(", %v from disabled rules", plural(s.Disabled, "issue"))
+	}
+
+	if s.Suppressed > 0 {
+		line += fmt.Sprintf(", %v suppressed", plural(s.Suppressed, "issue"))
+	}
+
+	_, err := fmt.Fprintf(r.output, "%v (%v)\n", line, summaryDuration(s.DurationMS))
+	return err
+}
+
+// summaryDuration returns ms formatted as milliseconds or, from one second on,
+// as seconds with one decimal.
+func summaryDuration(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+// severityDiagnostics returns a diagnostic for each unknown severity in
+// unknown, which maps the severities to the number of issues that had them.
+func severityDiagnostics(unknown map[string]int) []string {
+
+	severities := make([]string, 0, len(unknown))
+
+	for severity := range unknown {
+		severities = append(severities, severity)
+	}
+
+	sort.Strings(severities)
+
+	var result []string
+
+	for _, severity := range severities {
+		result = append(result, fmt.Sprintf("unknown severity %q normalized to %v (%v)", severity, SeverityError, plural(unknown[severity], "issue")))
+	}
+
+	return result
+}
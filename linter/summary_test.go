@@ -0,0 +1,211 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestSummarize(t *testing.T) {
+
+	issues := []Issue{
+		{File: "a.rego", Message: "x"},
+		{File: "a.rego", Message: "x", Severity: "error"},
+		{File: "b.rego", Message: "x", Severity: "warn"},
+		{File: "c.rego", Message: "x", Severity: "info"},
+		{Message: "x", Severity: "warning"},
+	}
+
+	expected := Summary{
+		FilesScanned:    10,
+		FilesWithIssues: 3,
+		Errors:          2,
+		Warnings:        2,
+		Infos:           1,
+		Failures:        2,
+		DurationMS:      1500,
+	}
+
+	if result := summarize(issues, 10, SeverityError, 1500*time.Millisecond+time.Microsecond); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, result)
+	}
+
+	failures := map[string]int{
+		SeverityError: 2,
+		SeverityWarn:  4,
+		SeverityInfo:  5,
+	}
+
+	for level, expected := range failures {
+		if result := summarize(issues, 10, level, 0); result.Failures != expected {
+			t.Errorf("Expected %v failures for %v but got %v", expected, level, result.Failures)
+		}
+	}
+}
+
+func TestExitReason(t *testing.T) {
+
+	tests := []struct {
+		report   Report
+		failOn   string
+		expected string
+	}{
+		{Report{Termination: Termination{Reason: TerminationCompleted}}, SeverityError, "no issues with severity error or higher"},
+		{Report{Termination: Termination{Reason: TerminationCompleted}, Summary: Summary{Failures: 1}}, SeverityError, "1 issue with severity error or higher"},
+		{Report{Termination: Termination{Reason: TerminationCompleted}, Summary: Summary{Failures: 3}}, SeverityWarn, "3 issues with severity warn or higher"},
+		{Report{Termination: Termination{Reason: TerminationTimeout, Limit: "5s"}}, SeverityError, "lint evaluation timed out after 5s"},
+		{Report{Termination: Termination{Reason: TerminationInterrupted}}, SeverityError, "lint evaluation interrupted"},
+	}
+
+	for _, tc := range tests {
+		if result := exitReason(&tc.report, tc.failOn); result != tc.expected {
+			t.Errorf("Expected %q but got %q", tc.expected, result)
+		}
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+
+	tests := []struct {
+		summary  Summary
+		expected string
+	}{
+		{Summary{FilesScanned: 148, FilesWithIssues: 7, Errors: 3, Warnings: 11, DurationMS: 1234}, "Scanned 148 files: 3 errors, 11 warnings in 7 files (1.2s)\n"},
+		{Summary{FilesScanned: 1, FilesWithIssues: 1, Errors: 1, DurationMS: 15}, "Scanned 1 file: 1 error, 0 warnings in 1 file (15ms)\n"},
+		{Summary{FilesScanned: 2, Errors: 1}, "Scanned 2 files: 1 error, 0 warnings (0ms)\n"},
+		{Summary{FilesScanned: 2, DurationMS: 999}, "Scanned 2 files: no issues found (999ms)\n"},
+		{Summary{FilesScanned: 2, FilesWithIssues: 1, Infos: 2}, "Scanned 2 files: 0 errors, 0 warnings, 2 infos in 1 file (0ms)\n"},
+		{Summary{FilesScanned: 2, Errors: 1, Disabled: 3}, "Scanned 2 files: 1 error, 0 warnings, 3 issues from disabled rules (0ms)\n"},
+		{Summary{FilesScanned: 2, Suppressed: 1}, "Scanned 2 files: no issues found, 1 issue suppressed (0ms)\n"},
+	}
+
+	for _, tc := range tests {
+
+		buf := bytes.NewBuffer(nil)
+
+		if err := New().SetOutput(buf).PrintSummary(&Report{Summary: tc.summary}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if buf.String() != tc.expected {
+			t.Errorf("Expected %q but got %q", tc.expected, buf.String())
+		}
+	}
+	report := &Report{
+		Issues:      []Issue{{Message: "x"}, {Message: "y"}},
+		Truncated:   true,
+		TotalIssues: 5,
+		Summary:     Summary{FilesScanned: 1, Errors: 5},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := New().SetOutput(buf).PrintSummary(report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "output truncated at 2 issues (5 found)\nScanned 1 file: 5 errors, 0 warnings (0ms)\n"
+
+	if buf.String() != expected {
+		t.Fatalf("Expected %q but got %q", expected, buf.String())
+	}
+}
+
+func TestLintSummaryIgnored(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego":        mustParseModule("a.rego", "package a\nfoo = true"),
+		"vendor/b.rego": mustParseModule("vendor/b.rego", "package b\nfoo = true"),
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(testFormatRules),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetLintModules(rules).SetIgnore([]string{"vendor"})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	summary := report.Summary
+	summary.DurationMS = 0
+
+	expected := Summary{FilesScanned: 1, FilesWithIssues: 1, Errors: 1, Warnings: 1, Failures: 1}
+
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, summary)
+	}
+}
+
+func TestLintSummaryFailOnInfo(t *testing.T) {
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", "package a\nfoo = true\nbar = true"),
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": ast.MustParseModule(`package system.lint
+
+deny[{"message": "foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+info[{"message": "bar", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "bar"
+}`),
+	}
+
+	tests := []struct {
+		failOn   string
+		failures int
+		reason   string
+	}{
+		{SeverityError, 1, "1 issue with severity error or higher"},
+		{SeverityInfo, 2, "2 issues with severity info or higher"},
+	}
+
+	for _, tc := range tests {
+
+		ctx := context.Background()
+		runner := New().SetModules(modules).SetLintModules(rules).SetFailOn(tc.failOn)
+
+		if err := runner.Compile(ctx); err != nil {
+			t.Fatalf("Unexpected compile error: %v", err)
+		}
+
+		report, err := runner.Lint(ctx, nil)
+		if err != nil {
+			t.Fatalf("Unexpected lint error: %v", err)
+		}
+
+		summary := report.Summary
+		summary.DurationMS = 0
+
+		expected := Summary{FilesScanned: 1, FilesWithIssues: 1, Errors: 1, Infos: 1, Failures: tc.failures}
+
+		if !reflect.DeepEqual(summary, expected) {
+			t.Errorf("%v: Expected %+v but got %+v", tc.failOn, expected, summary)
+		}
+
+		if report.ExitReason != tc.reason {
+			t.Errorf("%v: Expected exit reason %q but got %q", tc.failOn, tc.reason, report.ExitReason)
+		}
+	}
+}
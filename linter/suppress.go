@@ -0,0 +1,204 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// IgnoreDirective is the comment directive that suppresses issues on a single
+// line, e.g.:
+//
+//	# opa-lint:ignore no-foo,no-bar legacy rule kept for compatibility
+//	foo = true
+//
+// A directive on a line of its own applies to the next line. A directive
+// that follows other text applies to its own line. The directive is followed
+// by a comma-separated list of rule identifiers (or glob patterns, see
+// SetEnabledRules) and an optional justification. A directive without rule
+// identifiers suppresses the issues of all rules.
+const IgnoreDirective = "opa-lint:ignore"
+
+// suppression is an IgnoreDirective found in the source of file at row.
+// Issues of matching rules located at target are suppressed.
+type suppression struct {
+	file   string
+	row    int
+	target int
+	rules  rulePatterns
+	used   bool
+}
+
+func (s *suppression) match(issue Issue) bool {
+	if issue.File != s.file || issue.Row != s.target {
+		return false
+	}
+	return len(s.rules) == 0 || s.rules.Match(issue.RuleID)
+}
+
+func (s *suppression) String() string {
+	if len(s.rules) == 0 {
+		return IgnoreDirective
+	}
+	return IgnoreDirective + " " + strings.Join(s.rules, ",")
+}
+
+// suppressIssues returns the issues that are not suppressed by the
+// IgnoreDirective comments in the sources of modules and configs or by the
+// lint ignores declared in the METADATA blocks of the Runner's modules (see
+// annotationIgnore), and the number of issues that were suppressed. All of the
+// Runner's modules are considered for lint ignores since package and
+// subpackages scopes apply across files, even if those files are ignored,
+// filtered, or not sampled. Directives without rule identifiers and invalid
+// METADATA blocks in modules are described by diagnostics. Directives and
+// lint ignores in modules that did not suppress any issues are described by
+// notes.
+func (r *Runner) suppressIssues(issues []Issue, modules map[string]*ast.Module, configs map[string]interface{}) (kept []Issue, n int, diagnostics, notes []string) {
+
+	var suppressions []*suppression
+
+	files := make([]string, 0, len(modules)+len(configs))
+
+	for file := range modules {
+		files = append(files, file)
+	}
+
+	for file := range configs {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		src, ok := r.sources[file]
+		if !ok {
+			src = r.configs[file]
+		}
+		suppressions = append(suppressions, parseSuppressions(file, src)...)
+	}
+
+	index, diagnostics := newAnnotationIndex(r.modules, modules, r.sources)
+
+	if len(suppressions) == 0 && len(index.ignores) == 0 {
+		return issues, 0, diagnostics, nil
+	}
+
+	kept = make([]Issue, 0, len(issues))
+
+	for _, issue := range issues {
+		suppressed := false
+		for _, s := range suppressions {
+			if s.match(issue) {
+				s.used = true
+				suppressed = true
+			}
+		}
+		for _, a := range index.lookup(issue) {
+			if a.rules.Match(issue.RuleID) {
+				a.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, issue)
+		}
+	}
+
+	for _, s := range suppressions {
+		if len(s.rules) == 0 {
+			diagnostics = append(diagnostics, fmt.Sprintf("%v:%d: %v without rule identifiers suppresses all rules", s.file, s.row, IgnoreDirective))
+		}
+		if !s.used {
+			notes = append(notes, fmt.Sprintf("%v:%d: %v did not suppress any issues", s.file, s.row, s))
+		}
+	}
+
+	for _, a := range index.ignores {
+		if _, ok := modules[a.file]; !ok {
+			continue
+		}
+		if !a.used && len(a.rules) > 0 {
+			notes = append(notes, fmt.Sprintf("%v:%d: %v did not suppress any issues", a.file, a.row, a))
+		}
+	}
+
+	return kept, len(issues) - len(kept), diagnostics, notes
+}
+
+// parseSuppressions returns the IgnoreDirective comments contained in src.
+func parseSuppressions(file string, src []byte) []*suppression {
+
+	var result []*suppression
+
+	for i, line := range strings.Split(string(src), "\n") {
+
+		line = strings.TrimRight(line, "\r")
+
+		start := commentStart(line)
+		if start < 0 {
+			continue
+		}
+
+		comment := strings.TrimSpace(line[start+1:])
+
+		if !strings.HasPrefix(comment, IgnoreDirective) {
+			continue
+		}
+
+		rest := comment[len(IgnoreDirective):]
+
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			continue
+		}
+
+		s := &suppression{file: file, row: i + 1, target: i + 1}
+
+		if strings.TrimSpace(line[:start]) == "" {
+			s.target = i + 2
+		}
+
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			for _, id := range strings.Split(fields[0], ",") {
+				if id != "" {
+					s.rules = append(s.rules, id)
+				}
+			}
+		}
+
+		result = append(result, s)
+	}
+
+	return result
+}
+
+// commentStart returns the index of the "#" that starts the comment on line.
+// Characters inside double-quoted and raw string literals do not start
+// comments. If line does not contain a comment, -1 is returned.
+func commentStart(line string) int {
+
+	var quote rune
+
+	for i := 0; i < len(line); i++ {
+		c := rune(line[i])
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '#':
+			return i
+		}
+	}
+
+	return -1
+}
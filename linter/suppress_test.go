@@ -0,0 +1,128 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestParseSuppressions(t *testing.T) {
+
+	src := `package a
+
+# opa-lint:ignore no-foo,no-bar legacy rule
+foo = true
+bar = true # opa-lint:ignore no-bar
+baz = "# opa-lint:ignore no-baz"
+# opa-lint:ignore
+qux = true
+# opa-lint:ignored no-foo
+# opa-lint:ignore ,no-foo,
+`
+
+	result := parseSuppressions("a.rego", []byte(src))
+
+	expected := []*suppression{
+		{file: "a.rego", row: 3, target: 4, rules: rulePatterns{"no-foo", "no-bar"}},
+		{file: "a.rego", row: 5, target: 5, rules: rulePatterns{"no-bar"}},
+		{file: "a.rego", row: 7, target: 8},
+		{file: "a.rego", row: 10, target: 11, rules: rulePatterns{"no-foo"}},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, result)
+	}
+}
+
+func TestRunnerLintSuppressions(t *testing.T) {
+
+	src := `package a
+
+# opa-lint:ignore no-foo justified
+foo = true
+bar = true # opa-lint:ignore style/*
+baz = true
+# opa-lint:ignore
+qux = true
+# opa-lint:ignore no-todo
+quux = true
+`
+
+	modules := map[string]*ast.Module{
+		"a.rego": mustParseModule("a.rego", src),
+	}
+
+	rules := map[string]*ast.Module{
+		"lint.rego": mustParseModule("lint.rego", `package system.lint
+
+deny[{"id": "no-foo", "message": "foo", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "foo"
+}
+
+deny[{"id": "no-short-names", "message": rule.head.name, "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+}
+
+deny[{"id": "style/naming", "message": "naming", "location": rule.location}] {
+	rule = input.modules[_].rules[_]
+	rule.head.name = "bar"
+}`),
+	}
+
+	ctx := context.Background()
+	runner := New().SetModules(modules).SetLintModules(rules).SetSources(map[string][]byte{"a.rego": []byte(src)})
+
+	if err := runner.Compile(ctx); err != nil {
+		t.Fatalf("Unexpected compile error: %v", err)
+	}
+
+	report, err := runner.Lint(ctx, nil)
+	if err != nil {
+		t.Fatalf("Unexpected lint error: %v", err)
+	}
+
+	var result []string
+
+	for _, issue := range report.Issues {
+		result = append(result, issue.RuleID+" "+issue.Message)
+	}
+
+	sort.Strings(result)
+
+	// The first line suppresses only one of the rules that fire on it and
+	// the bare directive suppresses all rules of its line.
+	expected := []string{
+		"no-short-names bar",
+		"no-short-names baz",
+		"no-short-names foo",
+		"no-short-names quux",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, result)
+	}
+
+	if report.Summary.Suppressed != 3 {
+		t.Fatalf("Expected 3 suppressed issues but got: %+v", report.Summary)
+	}
+
+	diagnostics := []string{"a.rego:7: opa-lint:ignore without rule identifiers suppresses all rules"}
+
+	if !reflect.DeepEqual(report.Diagnostics, diagnostics) {
+		t.Fatalf("Expected diagnostics %v but got: %v", diagnostics, report.Diagnostics)
+	}
+
+	notes := []string{"a.rego:9: opa-lint:ignore no-todo did not suppress any issues"}
+
+	if !reflect.DeepEqual(report.Notes, notes) {
+		t.Fatalf("Expected notes %v but got: %v", notes, report.Notes)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// printTAP prints report in the Test Anything Protocol. Each linted file is a
+// test point that fails if the file has issues. The issues of a failed test
+// point are listed in a YAML diagnostic block.
+func (r *Runner) printTAP(report *Report) error {
+
+	byFile := map[string][]Issue{}
+
+	for _, issue := range sortedIssues(report.Issues) {
+		byFile[issue.File] = append(byFile[issue.File], issue)
+	}
+
+	files := r.lintedFiles()
+	linted := map[string]bool{}
+
+	for _, file := range files {
+		linted[file] = true
+	}
+
+	for file := range byFile {
+		if !linted[file] {
+			files = append(files, file)
+		}
+	}
+
+	sort.Strings(files)
+
+	w := bufio.NewWriter(r.output)
+
+	fmt.Fprintln(w, "TAP version 13")
+
+	if len(files) == 0 {
+		fmt.Fprintln(w, "1..0 # SKIP no files linted")
+		return w.Flush()
+	}
+
+	fmt.Fprintf(w, "1..%d\n", len(files))
+
+	for i, file := range files {
+
+		name := file
+		if name == "" {
+			name = "(no file)"
+		}
+
+		issues := byFile[file]
+
+		if len(issues) == 0 {
+			fmt.Fprintf(w, "ok %d - %v\n", i+1, name)
+			continue
+		}
+
+		fmt.Fprintf(w, "not ok %d - %v\n", i+1, name)
+		fmt.Fprintln(w, "  ---")
+		fmt.Fprintln(w, "  violations:")
+
+		for _, issue := range issues {
+			prefix := "    - "
+			if issue.RuleID != "" {
+				fmt.Fprintf(w, "%vrule: %v\n", prefix, strconv.Quote(issue.RuleID))
+				prefix = "      "
+			}
+			if issue.Row > 0 {
+				fmt.Fprintf(w, "%vline: %d\n", prefix, issue.Row)
+				prefix = "      "
+			}
+			fmt.Fprintf(w, "%vmessage: %v\n", prefix, strconv.Quote(issue.Message))
+		}
+
+		fmt.Fprintln(w, "  ...")
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// printTeamCity prints report as TeamCity service messages. Inspection types
+// are declared before the inspections themselves (see teamcityTypes). Issues
+// without a rule id are reported under the "opa-lint" inspection type.
+func (r *Runner) printTeamCity(report *Report) error {
+
+	issues := sortedIssues(report.Issues)
+	types := r.teamcityTypes(issues)
+	ids := make([]string, 0, len(types))
+
+	for id := range types {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	w := bufio.NewWriter(r.output)
+
+	for _, id := range ids {
+		fmt.Fprintf(w, "##teamcity[inspectionType id='%v' name='%v' description='%v' category='opa lint']\n",
+			teamcityEscape(id), teamcityEscape(id), teamcityEscape(types[id]))
+	}
+
+	for _, issue := range issues {
+		attrs := fmt.Sprintf("typeId='%v' message='%v' file='%v'", teamcityEscape(teamcityTypeID(issue)), teamcityEscape(issue.Message), teamcityEscape(issue.File))
+		if issue.Row > 0 {
+			attrs += fmt.Sprintf(" line='%d'", issue.Row)
+		}
+		fmt.Fprintf(w, "##teamcity[inspection %v SEVERITY='%v']\n", attrs, teamcitySeverity(issue.Level()))
+	}
+
+	return w.Flush()
+}
+
+// teamcityTypes returns the descriptions of the inspection types to declare
+// keyed by id. Types are declared for the rule ids of issues and, if the
+// Runner was compiled, for the enabled built-in checks and the rule ids that
+// the lint rules set to constant strings, so that rules without issues are
+// listed as well. Rule ids computed during evaluation are only declared if
+// they are reported. The constant "message" of a lint rule describes its type;
+// other types are described by their id.
+func (r *Runner) teamcityTypes(issues []Issue) map[string]string {
+
+	types := map[string]string{}
+
+	if r.compiler != nil {
+
+		for _, builtin := range builtinChecks {
+			if _, ok := r.disabled[builtin.id]; !ok {
+				types[builtin.id] = builtin.description
+			}
+		}
+
+		for _, query := range r.evalQueries {
+			for _, entry := range entrypoints(query) {
+				ref, err := ast.ParseRef(entry.query)
+				if err != nil {
+					continue
+				}
+				for _, rule := range r.compiler.GetRulesExact(ref.GroundPrefix()) {
+					id, description, ok := constantRuleID(rule, queryRuleID(entry.query))
+					if _, exists := types[id]; ok && !exists {
+						types[id] = description
+					}
+				}
+			}
+		}
+
+		for id := range types {
+			if !r.ruleEnabled(Issue{RuleID: id}) {
+				delete(types, id)
+			}
+		}
+	}
+
+	for _, issue := range issues {
+		if id := teamcityTypeID(issue); types[id] == "" {
+			types[id] = id
+		}
+	}
+
+	return types
+}
+
+// constantRuleID returns the rule id of the issues produced by rule and their
+// description if the id is constant. Issues without an "id" have the rule id
+// fallback. The description is the constant "message" of the issues or the
+// rule id.
+func constantRuleID(rule *ast.Rule, fallback string) (id, description string, ok bool) {
+
+	key := ruleKey(rule)
+	if key == nil {
+		return "", "", false
+	}
+
+	obj, isObject := key.Value.(ast.Object)
+	if !isObject {
+		return fallback, fallback, true
+	}
+
+	id = fallback
+
+	for _, item := range obj {
+		key, _ := item[0].Value.(ast.String)
+		value, isString := item[1].Value.(ast.String)
+		switch key {
+		case "id":
+			if !isString {
+				return "", "", false
+			}
+			id = string(value)
+		case "message":
+			if isString {
+				description = string(value)
+			}
+		}
+	}
+
+	if description == "" {
+		description = id
+	}
+
+	return id, description, true
+}
+
+// ruleKey returns the key of the partial set rule. The compiler rewrites keys
+// that are not ground into variables that are unified with the key in the
+// body, in which case the unified term is returned.
+func ruleKey(rule *ast.Rule) *ast.Term {
+
+	key := rule.Head.Key
+	if key == nil {
+		return nil
+	}
+
+	if _, ok := key.Value.(ast.Var); !ok {
+		return key
+	}
+
+	for _, expr := range rule.Body {
+		if !expr.IsEquality() {
+			continue
+		}
+		switch {
+		case expr.Operand(0).Equal(key):
+			return expr.Operand(1)
+		case expr.Operand(1).Equal(key):
+			return expr.Operand(0)
+		}
+	}
+
+	return key
+}
+
+func teamcityTypeID(issue Issue) string {
+	if issue.RuleID == "" {
+		return "opa-lint"
+	}
+	return issue.RuleID
+}
+
+// teamcitySeverity returns the TeamCity inspection severity of an issue with
+// level.
+func teamcitySeverity(level string) string {
+	switch level {
+	case SeverityWarn:
+		return "WARNING"
+	case SeverityInfo:
+		return "INFO"
+	}
+	return "ERROR"
+}
+
+var teamcityReplacer = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+	"\u0085", "|x",
+	"\u2028", "|l",
+	"\u2029", "|p",
+)
+
+// teamcityEscape escapes s for use as a service message attribute value.
+func teamcityEscape(s string) string {
+	return teamcityReplacer.Replace(s)
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the value that templates are executed against by the
+// go-template format.
+type TemplateData struct {
+	Issues      []Issue        // all issues sorted by file and position
+	Files       []TemplateFile // issues grouped by file
+	Summary     TemplateSummary
+	Sample      *SampleReport
+	Termination Termination
+}
+
+// TemplateFile contains the issues of a single file.
+type TemplateFile struct {
+	Name   string
+	Issues []Issue
+}
+
+// TemplateSummary contains the issue counts of a report.
+type TemplateSummary struct {
+	Issues     int            // number of issues
+	Files      int            // number of files with issues
+	Linted     int            // number of linted files
+	BySeverity map[string]int // number of issues by severity level (see Issue.Level)
+}
+
+// templateFuncs are the functions available to templates in addition to the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"json": func(x interface{}) (string, error) {
+		bs, err := json.Marshal(x)
+		return string(bs), err
+	},
+	"upper": strings.ToUpper,
+	"relpath": func(path string) string {
+		wd, err := os.Getwd()
+		if err != nil || !filepath.IsAbs(path) {
+			return path
+		}
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return path
+		}
+		return rel
+	},
+}
+
+// parseTemplate parses the template used by the go-template format.
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("report").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate prints report by executing the Runner's template against the
+// TemplateData of the report.
+func (r *Runner) printTemplate(report *Report) error {
+
+	if r.tmpl == nil {
+		return fmt.Errorf("go-template format requires a template")
+	}
+
+	data := TemplateData{
+		Issues:      sortedIssues(report.Issues),
+		Sample:      report.Sample,
+		Termination: report.Termination,
+		Summary: TemplateSummary{
+			Issues:     len(report.Issues),
+			Linted:     len(r.lintedFiles()),
+			BySeverity: map[string]int{},
+		},
+	}
+
+	for i, issue := range data.Issues {
+		if i == 0 || issue.File != data.Issues[i-1].File {
+			data.Files = append(data.Files, TemplateFile{Name: issue.File})
+		}
+		file := &data.Files[len(data.Files)-1]
+		file.Issues = append(file.Issues, issue)
+		data.Summary.BySeverity[issue.Level()]++
+	}
+
+	data.Summary.Files = len(data.Files)
+
+	if err := r.tmpl.Execute(r.output, data); err != nil {
+		return fmt.Errorf("template error: %v", err)
+	}
+
+	return nil
+}
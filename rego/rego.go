@@ -86,6 +86,7 @@ type Rego struct {
 	modules   []rawModule
 	compiler  *ast.Compiler
 	storage   *storage.Storage
+	txn       storage.Transaction
 	termVarID int
 }
 
@@ -143,6 +144,15 @@ func Storage(s *storage.Storage) func(r *Rego) {
 	}
 }
 
+// Transaction returns an argument that sets the transaction to use for
+// storage layer operations. If the transaction is not set, Eval will open and
+// close a transaction on the storage layer itself.
+func Transaction(txn storage.Transaction) func(r *Rego) {
+	return func(r *Rego) {
+		r.txn = txn
+	}
+}
+
 // New returns a new Rego object.
 func New(options ...func(*Rego)) *Rego {
 	r := &Rego{}
@@ -183,13 +193,16 @@ func (r *Rego) Eval(ctx context.Context) (ResultSet, error) {
 		return nil, err
 	}
 
-	// Prepare storage layer. Transaction could be an argument in the future.
-	txn, err := r.storage.NewTransaction(ctx)
-	if err != nil {
-		return nil, err
-	}
+	// Prepare storage layer.
+	txn := r.txn
 
-	defer r.storage.Close(ctx, txn)
+	if txn == nil {
+		txn, err = r.storage.NewTransaction(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer r.storage.Close(ctx, txn)
+	}
 
 	// Evaluate query
 	return r.eval(ctx, compiled, txn)
@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+
+	"github.com/open-policy-agent/opa/storage"
 )
 
 func TestRegoCaptureTermsRewrite(t *testing.T) {
@@ -45,3 +47,31 @@ func TestRegoCaptureTermsRewrite(t *testing.T) {
 		}
 	}
 }
+
+func TestRegoTransaction(t *testing.T) {
+
+	ctx := context.Background()
+
+	data := map[string]interface{}{
+		"x": json.Number("1"),
+	}
+
+	store := storage.New(storage.InMemoryWithJSONConfig(data))
+
+	if err := store.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Close(ctx, txn)
+
+	rs, err := New(
+		Query("data.x"),
+		Storage(store),
+		Transaction(txn),
+	).Eval(ctx)
+
+	if err != nil || len(rs) != 1 || !reflect.DeepEqual(rs[0].Expressions[0].Value, json.Number("1")) {
+		t.Fatalf("Unexpected result set: %v (err: %v)", rs, err)
+	}
+}
@@ -6,6 +6,7 @@ package runtime
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -40,11 +41,13 @@ func (e *loaderErrors) Add(err error) {
 
 type loaded struct {
 	Documents map[string]interface{}
-	Modules   map[string]*loadedModule
+	Modules   map[string]*LoadedModule
 	path      []string
 }
 
-type loadedModule struct {
+// LoadedModule contains a policy module loaded from disk along with the raw
+// source that it was parsed from.
+type LoadedModule struct {
 	Parsed *ast.Module
 	Raw    []byte
 }
@@ -52,7 +55,7 @@ type loadedModule struct {
 func newLoaded() *loaded {
 	return &loaded{
 		Documents: map[string]interface{}{},
-		Modules:   map[string]*loadedModule{},
+		Modules:   map[string]*LoadedModule{},
 	}
 }
 
@@ -96,7 +99,7 @@ func (e emptyModuleError) Error() string {
 
 func (l *loaded) Merge(path string, result interface{}) error {
 	switch result := result.(type) {
-	case *loadedModule:
+	case *LoadedModule:
 		l.Modules[normalizeModuleID(path)] = result
 	default:
 		obj, ok := makeDir(l.path, result)
@@ -114,7 +117,47 @@ func (l *loaded) Merge(path string, result interface{}) error {
 	return nil
 }
 
+// LoadOptions controls how data files are interpreted during loading.
+type LoadOptions struct {
+
+	// MultiDocumentYAML causes YAML files containing more than one document
+	// (separated by "---") to be loaded as an array of the documents in the
+	// order they appear in the file. By default, only the first document of a
+	// YAML file is loaded.
+	MultiDocumentYAML bool
+
+	// JSONC causes "//" and "/* */" comments in JSON files to be ignored.
+	JSONC bool
+
+	// Skip is called with the path of each file before it is loaded. Files
+	// for which Skip returns true are not loaded.
+	Skip func(path string) bool
+}
+
+// LoadPaths reads the data documents and policy modules contained in paths.
+// Directories are loaded recursively. Paths may be prefixed with the desired
+// destination in the data document using the "<dotted-path>:<file-path>"
+// syntax accepted by the run command. The returned modules are keyed by file
+// path.
+func LoadPaths(paths []string) (map[string]interface{}, map[string]*LoadedModule, error) {
+	return LoadPathsWithOptions(paths, LoadOptions{})
+}
+
+// LoadPathsWithOptions is the same as LoadPaths except that opts controls how
+// data files are interpreted.
+func LoadPathsWithOptions(paths []string, opts LoadOptions) (map[string]interface{}, map[string]*LoadedModule, error) {
+	loaded, err := loadPaths(paths, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return loaded.Documents, loaded.Modules, nil
+}
+
 func loadAllPaths(paths []string) (*loaded, error) {
+	return loadPaths(paths, LoadOptions{})
+}
+
+func loadPaths(paths []string, opts LoadOptions) (*loaded, error) {
 
 	root := newLoaded()
 	errors := loaderErrors{}
@@ -136,9 +179,9 @@ func loadAllPaths(paths []string) (*loaded, error) {
 		}
 
 		if info.IsDir() {
-			loadDirRecursive(&errors, path, loaded.WithParent(info.Name()))
-		} else {
-			result, err := loadFile(path)
+			loadDirRecursive(&errors, path, loaded.WithParent(info.Name()), opts)
+		} else if opts.Skip == nil || !opts.Skip(path) {
+			result, err := loadFile(path, opts)
 			if err != nil {
 				errors.Add(err)
 			} else {
@@ -156,7 +199,7 @@ func loadAllPaths(paths []string) (*loaded, error) {
 	return root, nil
 }
 
-func loadDirRecursive(errors *loaderErrors, dirPath string, loaded *loaded) {
+func loadDirRecursive(errors *loaderErrors, dirPath string, loaded *loaded, opts LoadOptions) {
 	files, err := ioutil.ReadDir(dirPath)
 	if err != nil {
 		errors.Add(err)
@@ -169,9 +212,9 @@ func loadDirRecursive(errors *loaderErrors, dirPath string, loaded *loaded) {
 			errors.Add(err)
 		} else {
 			if info.IsDir() {
-				loadDirRecursive(errors, filePath, loaded.WithParent(info.Name()))
-			} else {
-				result, err := loadFileForKnownTypes(filePath)
+				loadDirRecursive(errors, filePath, loaded.WithParent(info.Name()), opts)
+			} else if opts.Skip == nil || !opts.Skip(filePath) {
+				result, err := loadFileForKnownTypes(filePath, opts)
 				if err != nil {
 					if _, ok := err.(unrecognizedFile); !ok {
 						errors.Add(err)
@@ -186,13 +229,19 @@ func loadDirRecursive(errors *loaderErrors, dirPath string, loaded *loaded) {
 	}
 }
 
-func loadFileForKnownTypes(path string) (interface{}, error) {
+func loadFileForKnownTypes(path string, opts LoadOptions) (interface{}, error) {
 	switch filepath.Ext(path) {
 	case ".json":
+		if opts.JSONC {
+			return jsoncLoad(path)
+		}
 		return jsonLoad(path)
 	case ".rego":
 		return regoLoad(path)
 	case ".yaml", ".yml":
+		if opts.MultiDocumentYAML {
+			return yamlMultiDocumentLoad(path)
+		}
 		return yamlLoad(path)
 	}
 	return nil, unrecognizedFile(path)
@@ -214,8 +263,8 @@ func loadFileForAnyType(path string) (interface{}, error) {
 	return nil, unrecognizedFile(path)
 }
 
-func loadFile(path string) (interface{}, error) {
-	result, err := loadFileForKnownTypes(path)
+func loadFile(path string, opts LoadOptions) (interface{}, error) {
+	result, err := loadFileForKnownTypes(path, opts)
 	if err != nil {
 		if isUnrecognizedFile(err) {
 			return loadFileForAnyType(path)
@@ -251,7 +300,7 @@ func regoLoad(path string) (interface{}, error) {
 	if module == nil {
 		return nil, emptyModuleError(path)
 	}
-	result := &loadedModule{
+	result := &LoadedModule{
 		Parsed: module,
 		Raw:    bs,
 	}
@@ -270,6 +319,47 @@ func yamlLoad(path string) (interface{}, error) {
 	return x, nil
 }
 
+func jsoncLoad(path string) (interface{}, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stripped := stripJSONComments(bs)
+	decoder := util.NewJSONDecoder(bytes.NewBuffer(stripped))
+	var x interface{}
+	if err := decoder.Decode(&x); err != nil {
+		if serr, ok := err.(*json.SyntaxError); ok {
+			row, col := offsetToPosition(bs, serr.Offset)
+			return nil, fmt.Errorf("%v:%v:%v: %v", path, row, col, err)
+		}
+		return nil, errors.Wrapf(err, path)
+	}
+	return x, nil
+}
+
+func yamlMultiDocumentLoad(path string) (interface{}, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	docs := splitYAMLDocuments(bs)
+	if len(docs) <= 1 {
+		// The file is parsed as is so that errors refer to its lines.
+		var x interface{}
+		if err := unmarshalYAML(bs, &x); err != nil {
+			return nil, errors.Wrapf(err, path)
+		}
+		return x, nil
+	}
+	result := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		if err := unmarshalYAML(doc.text, &result[i]); err != nil {
+			return nil, fmt.Errorf("%v: document %v (line %v): %v", path, i, doc.row, offsetYAMLErrorLines(err, doc.row-1))
+		}
+	}
+	return result, nil
+}
+
 func makeDir(path []string, x interface{}) (map[string]interface{}, bool) {
 	if len(path) == 0 {
 		obj, ok := x.(map[string]interface{})
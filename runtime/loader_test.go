@@ -214,6 +214,181 @@ func TestLoadErrors(t *testing.T) {
 	})
 }
 
+func TestLoadMultiDocumentYAML(t *testing.T) {
+
+	files := map[string]string{
+		"/multi.yaml": `# leading comment
+---
+a: 1
+---
+b: [1, 2]
+...
+---
+# only comments
+---
+c: "x"
+`,
+		"/single.yaml": `---
+a: 1
+`,
+	}
+
+	withTempFS(files, func(rootDir string) {
+
+		multi := filepath.Join(rootDir, "multi.yaml")
+		docs, _, err := LoadPathsWithOptions([]string{"x:" + multi}, LoadOptions{MultiDocumentYAML: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := parseJSON(`{"x": [{"a": 1}, {"b": [1, 2]}, {"c": "x"}]}`)
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, docs)
+		}
+
+		single := filepath.Join(rootDir, "single.yaml")
+		docs, _, err = LoadPathsWithOptions([]string{single}, LoadOptions{MultiDocumentYAML: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected = parseJSON(`{"a": 1}`)
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, docs)
+		}
+
+		docs, _, err = LoadPaths([]string{"x:" + multi})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected = parseJSON(`{"x": {"a": 1}}`)
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("Expected only first document to be loaded by default but got: %v", docs)
+		}
+	})
+}
+
+func TestLoadMultiDocumentYAMLErrors(t *testing.T) {
+
+	files := map[string]string{
+		"/bad.yaml": `a: 1
+---
+b: 2
+---
+c:
+  - d
+ e: f
+`,
+	}
+
+	withTempFS(files, func(rootDir string) {
+		_, _, err := LoadPathsWithOptions([]string{"x:" + filepath.Join(rootDir, "bad.yaml")}, LoadOptions{MultiDocumentYAML: true})
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		for _, s := range []string{"bad.yaml: document 2 (line 4)", "yaml: line 6"} {
+			if !strings.Contains(err.Error(), s) {
+				t.Fatalf("Expected error to contain %q but got: %v", s, err)
+			}
+		}
+	})
+}
+
+func TestLoadMultiDocumentYAMLSingleDocumentError(t *testing.T) {
+
+	files := map[string]string{
+		"/bad.yaml": "# c\n# c\n\na: 1\nb: [\n",
+	}
+
+	withTempFS(files, func(rootDir string) {
+		_, _, err := LoadPathsWithOptions([]string{filepath.Join(rootDir, "bad.yaml")}, LoadOptions{MultiDocumentYAML: true})
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		_, _, expected := LoadPaths([]string{filepath.Join(rootDir, "bad.yaml")})
+		if expected == nil || err.Error() != expected.Error() {
+			t.Fatalf("Expected error %v but got: %v", expected, err)
+		}
+	})
+}
+
+func TestLoadJSONC(t *testing.T) {
+
+	files := map[string]string{
+		"/foo.json": `{
+	// line comment
+	"a": "http://example.com/*not a comment*/", /* block
+	comment */ "b": [1, 2] // trailing
+}`,
+		"/bad.json": `{
+	/* comment */
+	"a": 1,,
+}`,
+	}
+
+	withTempFS(files, func(rootDir string) {
+
+		docs, _, err := LoadPathsWithOptions([]string{filepath.Join(rootDir, "foo.json")}, LoadOptions{JSONC: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := parseJSON(`{"a": "http://example.com/*not a comment*/", "b": [1, 2]}`)
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, docs)
+		}
+
+		_, _, err = LoadPaths([]string{filepath.Join(rootDir, "foo.json")})
+		if err == nil {
+			t.Fatal("Expected error loading JSON with comments by default")
+		}
+
+		_, _, err = LoadPathsWithOptions([]string{filepath.Join(rootDir, "bad.json")}, LoadOptions{JSONC: true})
+		if err == nil || !strings.Contains(err.Error(), "bad.json:3:") {
+			t.Fatalf("Expected error with position in original file but got: %v", err)
+		}
+	})
+}
+
+func TestLoadSkip(t *testing.T) {
+
+	files := map[string]string{
+		"/a.json":          `{"a": 1}`,
+		"/dir/config.yaml": `{"b": 2`,
+		"/dir/c.json":      `{"c": 3}`,
+	}
+
+	withTempFS(files, func(rootDir string) {
+
+		var skipped []string
+
+		opts := LoadOptions{
+			Skip: func(path string) bool {
+				if filepath.Base(path) == "config.yaml" || filepath.Base(path) == "a.json" {
+					skipped = append(skipped, filepath.Base(path))
+					return true
+				}
+				return false
+			},
+		}
+
+		docs, _, err := LoadPathsWithOptions([]string{filepath.Join(rootDir, "a.json"), filepath.Join(rootDir, "dir")}, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := parseJSON(`{"dir": {"c": 3}}`)
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, docs)
+		}
+
+		if !reflect.DeepEqual(skipped, []string{"a.json", "config.yaml"}) {
+			t.Fatalf("Expected a.json and config.yaml to be skipped but got: %v", skipped)
+		}
+	})
+}
+
 func withTempFS(files map[string]string, f func(string)) {
 	rootDir, cleanup, err := makeTempFS(files)
 	if err != nil {
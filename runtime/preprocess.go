@@ -0,0 +1,168 @@
+// Copyright 2017 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// yamlDocument represents a single document contained in a YAML file.
+type yamlDocument struct {
+	text []byte
+	row  int // The line in the file where the document starts.
+}
+
+// splitYAMLDocuments returns the documents contained in bs. Documents are
+// separated by "---" and may be terminated by "...". Documents that contain
+// only whitespace and comments are discarded.
+func splitYAMLDocuments(bs []byte) []yamlDocument {
+
+	var docs []yamlDocument
+	var buf bytes.Buffer
+	start := 1
+
+	flush := func() {
+		if !isBlankYAML(buf.Bytes()) {
+			docs = append(docs, yamlDocument{
+				text: append([]byte(nil), buf.Bytes()...),
+				row:  start,
+			})
+		}
+		buf.Reset()
+	}
+
+	for i, line := range bytes.Split(bs, []byte("\n")) {
+		row := i + 1
+		switch {
+		case isYAMLMarker(line, "---"):
+			flush()
+			start = row
+			// Content may follow the marker on the same line. Blank out the
+			// marker so that columns in error messages remain accurate.
+			buf.WriteString("   ")
+			buf.Write(line[3:])
+			buf.WriteByte('\n')
+		case isYAMLMarker(line, "..."):
+			flush()
+			start = row + 1
+		default:
+			if buf.Len() == 0 && isBlankYAML(line) {
+				start = row + 1
+				continue
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+
+	flush()
+
+	return docs
+}
+
+func isYAMLMarker(line []byte, marker string) bool {
+	if !bytes.HasPrefix(line, []byte(marker)) {
+		return false
+	}
+	rest := line[len(marker):]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r'
+}
+
+func isBlankYAML(bs []byte) bool {
+	for _, line := range bytes.Split(bs, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+var yamlErrorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// offsetYAMLErrorLines returns an error equivalent to err with line numbers
+// reported by the YAML parser shifted by offset.
+func offsetYAMLErrorLines(err error, offset int) error {
+	msg := yamlErrorLineRegexp.ReplaceAllStringFunc(err.Error(), func(s string) string {
+		n, _ := strconv.Atoi(s[len("line "):])
+		return fmt.Sprintf("line %v", n+offset)
+	})
+	return fmt.Errorf("%v", msg)
+}
+
+// stripJSONComments returns a copy of bs with "//" and "/* */" comments
+// replaced by spaces. Newlines are preserved so that offsets into the result
+// refer to the same positions in bs.
+func stripJSONComments(bs []byte) []byte {
+
+	result := make([]byte, len(bs))
+	copy(result, bs)
+
+	const (
+		code = iota
+		str
+		lineComment
+		blockComment
+	)
+
+	state := code
+
+	for i := 0; i < len(result); i++ {
+		c := result[i]
+		switch state {
+		case code:
+			if c == '"' {
+				state = str
+			} else if c == '/' && i+1 < len(result) {
+				switch result[i+1] {
+				case '/':
+					state = lineComment
+					result[i], result[i+1] = ' ', ' '
+					i++
+				case '*':
+					state = blockComment
+					result[i], result[i+1] = ' ', ' '
+					i++
+				}
+			}
+		case str:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				state = code
+			}
+		case lineComment:
+			if c == '\n' {
+				state = code
+			} else {
+				result[i] = ' '
+			}
+		case blockComment:
+			if c == '*' && i+1 < len(result) && result[i+1] == '/' {
+				state = code
+				result[i], result[i+1] = ' ', ' '
+				i++
+			} else if c != '\n' {
+				result[i] = ' '
+			}
+		}
+	}
+
+	return result
+}
+
+// offsetToPosition returns the row and column of the byte at offset in bs.
+func offsetToPosition(bs []byte, offset int64) (int, int) {
+	if offset > int64(len(bs)) {
+		offset = int64(len(bs))
+	}
+	prefix := bs[:offset]
+	row := bytes.Count(prefix, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(prefix, '\n')
+	return row, col
+}
@@ -266,7 +266,7 @@ func (rt *Runtime) getBanner() string {
 	return buf.String()
 }
 
-func compileAndStoreInputs(modules map[string]*loadedModule, store *storage.Storage, txn storage.Transaction) error {
+func compileAndStoreInputs(modules map[string]*LoadedModule, store *storage.Storage, txn storage.Transaction) error {
 
 	policies := store.ListPolicies(txn)
 
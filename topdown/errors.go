@@ -32,6 +32,10 @@ const (
 	// TypeErr indicates evaluation stopped because an expression was applied to
 	// a value of an inappropriate type.
 	TypeErr string = "eval_type_error"
+
+	// CancelErr indicates evaluation stopped because the context passed to
+	// the evaluation engine was cancelled or its deadline expired.
+	CancelErr string = "eval_cancel_error"
 )
 
 // IsError returns true if the err is an Error.
@@ -67,6 +71,14 @@ func objectDocKeyConflictErr(loc *ast.Location) error {
 	}
 }
 
+func cancelErr(err error, loc *ast.Location) error {
+	return &Error{
+		Code:     CancelErr,
+		Location: loc,
+		Message:  err.Error(),
+	}
+}
+
 func unsupportedBuiltinErr(loc *ast.Location) error {
 	return &Error{
 		Code:     InternalErr,
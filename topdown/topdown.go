@@ -758,6 +758,10 @@ func ResolveRefsTerm(term *ast.Term, t *Topdown) (*ast.Term, error) {
 
 func eval(t *Topdown, iter Iterator) error {
 
+	if err := checkCancel(t); err != nil {
+		return err
+	}
+
 	if t.Index >= len(t.Query) {
 		return iter(t)
 	}
@@ -772,6 +776,24 @@ func eval(t *Topdown, iter Iterator) error {
 	})
 }
 
+// checkCancel returns an error if the context that t is being evaluated with
+// has been cancelled.
+func checkCancel(t *Topdown) error {
+	if t.Context == nil {
+		return nil
+	}
+	select {
+	case <-t.Context.Done():
+		var loc *ast.Location
+		if t.Index < len(t.Query) {
+			loc = t.Current().Location
+		}
+		return cancelErr(t.Context.Err(), loc)
+	default:
+		return nil
+	}
+}
+
 func evalStep(t *Topdown, iter Iterator) error {
 
 	if t.Current().Negated {
@@ -1453,6 +1453,32 @@ func TestTopDownUnsupportedBuiltin(t *testing.T) {
 
 }
 
+func TestTopDownCancel(t *testing.T) {
+
+	body := ast.MustParseBody(`x = [1, 2, 3]; x[_] = y`)
+	ctx, cancel := context.WithCancel(context.Background())
+	compiler := ast.NewCompiler()
+	store := storage.New(storage.InMemoryConfig())
+	txn := storage.NewTransactionOrDie(ctx, store)
+	top := New(ctx, body, compiler, store, txn)
+
+	var results int
+
+	err := Eval(top, func(*Topdown) error {
+		results++
+		cancel()
+		return nil
+	})
+
+	if results != 1 {
+		t.Fatalf("Expected evaluation to stop after first result but got %v results", results)
+	}
+
+	if e, ok := err.(*Error); !ok || e.Code != CancelErr {
+		t.Fatalf("Expected cancel error but got: %v", err)
+	}
+}
+
 type contextPropagationMock struct{}
 
 // contextPropagationStore will accumulate values from the contexts provided to